@@ -10,7 +10,7 @@ func main() {
 
 type ExampleRequest struct {
 	ID          interface{} `json:"id,omitempty" form:"id"`
-	Name        string      `json:"name,omitempty" form:"name"`
+	Name        string      `json:"name,omitempty" form:"name" binding:"required,max=64"`
 	Description string      `json:"description,omitempty" form:"description"`
 }
 
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -122,6 +123,31 @@ func withForm(data interface{}) testOpt {
 	}
 }
 
+func withMultipart(fields map[string]string, files map[string]string) testOpt {
+	return func(c *gin.Context) {
+		if c.Request.Header == nil {
+			c.Request.Header = make(http.Header)
+		}
+
+		b := &bytes.Buffer{}
+		w := multipart.NewWriter(b)
+		// fixed so that the generated file doesn't change between runs
+		w.SetBoundary("autodoc-example-boundary")
+		for k, v := range fields {
+			w.WriteField(k, v)
+		}
+		for k, v := range files {
+			f, _ := w.CreateFormFile(k, v)
+			f.Write([]byte("file-content"))
+		}
+		w.Close()
+
+		c.Request.Header.Set("Content-Type", w.FormDataContentType())
+		c.Request.ContentLength = int64(b.Len())
+		c.Request.Body = ioutil.NopCloser(b)
+	}
+}
+
 func TestExampleFormHandler(t *testing.T) {
 	recorder := autodoc.Recorder{
 		Path:   "/api/v1/example-form",
@@ -252,9 +278,76 @@ func TestJSONHandler(t *testing.T) {
 
 			recorder.RecordGin(ExampleHandler(tt.args.statusCode, tt.args.resp), autodoc.RecordOptions{
 				UseAsRequestExample: true,
+				RequestType:         ExampleRequest{},
+				T:                   t,
 			})(c)
 
 			recorder.GenerateFile()
 		})
 	}
 }
+
+func TestExampleUploadHandler(t *testing.T) {
+	recorder := autodoc.Recorder{
+		Path:   "/api/v1/example-upload",
+		Method: "post",
+		Tag:    "Example",
+	}
+	type args struct {
+		statusCode int
+		fields     map[string]string
+		files      map[string]string
+		resp       interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "Test Example",
+			args: args{
+				statusCode: 200,
+				fields:     map[string]string{"name": "name-example"},
+				files:      map[string]string{"avatar": "avatar.png"},
+				resp:       gin.H{"message": "success"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := createTestContext(withMultipart(tt.args.fields, tt.args.files))
+			c.Request.Method = "POST"
+
+			recorder.RecordGin(ExampleHandler(tt.args.statusCode, tt.args.resp), autodoc.RecordOptions{
+				UseAsRequestExample: true,
+			})(c)
+
+			rec := recorder.Records[len(recorder.Records)-1]
+			params := map[string]string{}
+			for _, p := range rec.Request.PostData.Params {
+				params[p.Name] = p.Value
+			}
+			if !reflect.DeepEqual(params, tt.args.fields) {
+				t.Errorf("params = %v, want %v", params, tt.args.fields)
+			}
+			wantFiles := []autodoc.FormFile{{
+				Name:        "avatar",
+				Filename:    "avatar.png",
+				ContentType: "application/octet-stream",
+				Size:        int64(len("file-content")),
+			}}
+			if !reflect.DeepEqual(rec.Files, wantFiles) {
+				t.Errorf("files = %+v, want %+v", rec.Files, wantFiles)
+			}
+
+			body := recorder.OpenAPI().Paths[recorder.Path].(map[string]interface{})["post"].(map[string]interface{})["requestBody"].(autodoc.RequestBody)
+			props := body.Content["multipart/form-data"].Schema["properties"].(map[string]interface{})
+			avatar := props["avatar"].(map[string]interface{})
+			if avatar["type"] != "string" || avatar["format"] != "binary" {
+				t.Errorf("avatar schema = %v, want a binary string", avatar)
+			}
+
+			recorder.GenerateFile()
+		})
+	}
+}
@@ -75,15 +75,16 @@ func (inst *instance) getFiles() (paths []string) {
 	return paths
 }
 
-func (inst *instance) fileToRecorder(path string) (r autodoc.Recorder, err error) {
+func (inst *instance) fileToRecorder(path string) (*autodoc.Recorder, error) {
 	f, err := ioutil.ReadFile(path)
 	if err != nil {
-		return r, err
+		return nil, err
 	}
 
-	err = json.Unmarshal(f, &r)
+	r := &autodoc.Recorder{}
+	err = json.Unmarshal(f, r)
 	if err != nil {
-		return r, err
+		return nil, err
 	}
 
 	return r, nil
@@ -117,7 +118,11 @@ func (inst *instance) openAPI() error {
 			return err
 		}
 
-		o := recorder.OpenAPI()
+		o := recorder.OpenAPI(inst.config.OpenAPIConfig)
+		for _, w := range o.Warnings {
+			fmt.Println("warning:", w)
+		}
+		all.Components = mergeComponents(all.Components, o.Components)
 
 		for path, m := range o.Paths {
 			m := m.(map[string]interface{})
@@ -131,6 +136,8 @@ func (inst *instance) openAPI() error {
 		}
 	}
 
+	all.DeduplicateSchemas()
+
 	y, err := yaml.Marshal(all)
 	if err != nil {
 		return err
@@ -139,9 +146,39 @@ func (inst *instance) openAPI() error {
 	return inst.writeFile(y, "openapi.yaml")
 }
 
+// mergeComponents adds the components generated for a recorder to the
+// existing ones. existing entries, including those from the config, are kept
+func mergeComponents(dst, src map[string]interface{}) map[string]interface{} {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+
+	for section, s := range src {
+		s, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		d, ok := dst[section].(map[string]interface{})
+		if !ok {
+			d = map[string]interface{}{}
+			dst[section] = d
+		}
+		for name, v := range s {
+			if _, ok := d[name]; !ok {
+				d[name] = v
+			}
+		}
+	}
+	return dst
+}
+
 func (inst *instance) postmanCollection() error {
 	paths := inst.getFiles()
-	folders := map[string][]autodoc.Recorder{}
+	folders := map[string][]*autodoc.Recorder{}
 	for _, path := range paths {
 		fmt.Println("found autodoc file:", path)
 
@@ -207,31 +244,7 @@ func (inst *instance) postmanCollection() error {
 				},
 			})
 
-			if req.Request.PostData != nil {
-				switch req.Request.PostData.MimeType {
-				case "application/x-www-form-urlencoded":
-					form := []map[string]interface{}{}
-					item.Request.Body = &postman.Body{
-						Mode: "urlencoded",
-					}
-
-					for _, f := range req.Request.PostData.Params {
-						form = append(form, map[string]interface{}{
-							"key":      f.Name,
-							"value":    f.Value,
-							"required": true,
-						})
-					}
-
-					item.Request.Body.FormData = form
-
-				default:
-					item.Request.Body = &postman.Body{
-						Mode: "json", //TODO:
-						Raw:  req.Request.PostData.Text,
-					}
-				}
-			}
+			item.Request.Body = postmanBody(req)
 
 			folder.AddItem(item)
 		}
@@ -246,6 +259,60 @@ func (inst *instance) postmanCollection() error {
 	return inst.writeFile(b.Bytes(), "postman_collection.json")
 }
 
+// postmanBody converts the body of a recorded request to a postman request
+// body. form files only keep their file name
+func postmanBody(e autodoc.Entry) *postman.Body {
+	if e.Request.PostData == nil {
+		return nil
+	}
+
+	switch e.Request.PostData.MimeType {
+	case "application/x-www-form-urlencoded":
+		form := []map[string]interface{}{}
+		for _, f := range e.Request.PostData.Params {
+			form = append(form, map[string]interface{}{
+				"key":      f.Name,
+				"value":    f.Value,
+				"required": true,
+			})
+		}
+
+		return &postman.Body{
+			Mode:     "urlencoded",
+			FormData: form,
+		}
+
+	case "multipart/form-data":
+		form := []map[string]interface{}{}
+		for _, f := range e.Request.PostData.Params {
+			form = append(form, map[string]interface{}{
+				"key":   f.Name,
+				"value": f.Value,
+				"type":  "text",
+			})
+		}
+
+		for _, f := range e.Files {
+			form = append(form, map[string]interface{}{
+				"key":  f.Name,
+				"src":  f.Filename,
+				"type": "file",
+			})
+		}
+
+		return &postman.Body{
+			Mode:     "formdata",
+			FormData: form,
+		}
+
+	default:
+		return &postman.Body{
+			Mode: "json", //TODO:
+			Raw:  e.Request.PostData.Text,
+		}
+	}
+}
+
 func writeDefaultConfig(path string) error {
 	os.MkdirAll(filepath.Dir(path), os.ModePerm)
 	f, err := os.Create(path)
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	autodoc "github.com/arpinfidel/autodoc/record"
+)
+
+func TestPostmanBodyFormData(t *testing.T) {
+	b := &bytes.Buffer{}
+	w := multipart.NewWriter(b)
+	w.WriteField("name", "foo")
+	f, _ := w.CreateFormFile("avatar", "avatar.png")
+	f.Write([]byte("file-content"))
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", b)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	re := autodoc.Recorder{Path: "/upload", Method: "post"}
+	re.Record(func(w http.ResponseWriter, r *http.Request) {})(httptest.NewRecorder(), req)
+
+	body := postmanBody(re.Records[0])
+	want := []map[string]interface{}{
+		{"key": "name", "value": "foo", "type": "text"},
+		{"key": "avatar", "src": "avatar.png", "type": "file"},
+	}
+	if body == nil || body.Mode != "formdata" || !reflect.DeepEqual(body.FormData, want) {
+		t.Errorf("postmanBody() = %+v, want formdata %v", body, want)
+	}
+}
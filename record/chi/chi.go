@@ -0,0 +1,27 @@
+// Package autodocchi records the requests handled by a chi router
+package autodocchi
+
+import (
+	"net/http"
+	"strings"
+
+	autodoc "github.com/arpinfidel/autodoc/record"
+	"github.com/go-chi/chi/v5"
+)
+
+// Middleware records the requests handled by a chi router with the
+// registry's recorder for their route pattern, e.g. /users/{id}
+func Middleware(reg *autodoc.Registry, opts ...autodoc.RecordOptions) func(http.Handler) http.Handler {
+	return reg.Middleware(func(r *http.Request) string {
+		rctx := chi.RouteContext(r.Context())
+		if rctx == nil {
+			return ""
+		}
+		// sub-router patterns are joined with an extra slash, e.g. /users//
+		path := rctx.RoutePattern()
+		for strings.Contains(path, "//") {
+			path = strings.ReplaceAll(path, "//", "/")
+		}
+		return path
+	}, opts...)
+}
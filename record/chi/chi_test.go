@@ -0,0 +1,88 @@
+package autodocchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	autodoc "github.com/arpinfidel/autodoc/record"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMiddleware(t *testing.T) {
+	reg := &autodoc.Registry{Tag: "users"}
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := chi.NewRouter()
+	r.Use(Middleware(reg))
+	r.Route("/users", func(r chi.Router) {
+		r.Get("/", h)
+		r.Get("/{id:[0-9]+}", h)
+		r.Post("/", h)
+	})
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/users/", nil),
+		httptest.NewRequest(http.MethodGet, "/users/1", nil),
+		httptest.NewRequest(http.MethodGet, "/users/2", nil),
+		httptest.NewRequest(http.MethodPost, "/users/", nil),
+		httptest.NewRequest(http.MethodGet, "/posts", nil),
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	want := []string{"get /users/", "post /users/", "get /users/{id}"}
+	if got := reg.Routes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %v, want %v", got, want)
+	}
+	if got := len(reg.Recorder("get", "/users/{id}").Records); got != 2 {
+		t.Errorf("/users/{id} records = %d, want 2", got)
+	}
+	if tag := reg.Recorder("GET", "/users/{id}").Tag; tag != "users" {
+		t.Errorf("tag = %q, want users", tag)
+	}
+
+	o := reg.Recorder("get", "/users/{id}").OpenAPI()
+	if len(o.Warnings) > 0 {
+		t.Errorf("warnings = %v", o.Warnings)
+	}
+}
+
+func TestMiddlewareConcurrent(t *testing.T) {
+	t.Parallel()
+	reg := &autodoc.Registry{}
+
+	r := chi.NewRouter()
+	r.Use(Middleware(reg))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r.Get("/posts/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	const n = 50
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			url := "/users/" + strconv.Itoa(i)
+			if i%2 == 0 {
+				url = "/posts/" + strconv.Itoa(i)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+		}(i)
+	}
+	wg.Wait()
+
+	records := 0
+	for _, re := range reg.Recorders() {
+		records += len(re.Records)
+		if len(re.OpenAPI().Warnings) > 0 {
+			t.Errorf("%s warnings = %v", re.Path, re.OpenAPI().Warnings)
+		}
+	}
+	if records != n {
+		t.Errorf("records = %d, want %d", records, n)
+	}
+}
@@ -0,0 +1,271 @@
+package autodoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaComponent is an object schema that is moved into components/schemas
+type schemaComponent struct {
+	name  string
+	count int
+	done  bool
+}
+
+type deduplicator struct {
+	components map[string]*schemaComponent
+	names      map[string]bool
+	schemas    map[string]interface{}
+}
+
+// DeduplicateSchemas moves object schemas that appear more than once, or that
+// were given a name through RecordOptions, into components/schemas and
+// replaces the inline copies with a $ref. schemas already in the components
+// are kept and reused when an inline schema has the same structure
+func (o *OpenAPI) DeduplicateSchemas() {
+	paths, _ := normalize(o.Paths).(map[string]interface{})
+	if paths == nil {
+		return
+	}
+
+	components, _ := normalize(o.Components).(map[string]interface{})
+	if components == nil {
+		components = map[string]interface{}{}
+	}
+	schemas, _ := components["schemas"].(map[string]interface{})
+	if schemas == nil {
+		schemas = map[string]interface{}{}
+	}
+
+	d := &deduplicator{
+		components: map[string]*schemaComponent{},
+		names:      map[string]bool{},
+		schemas:    schemas,
+	}
+
+	for _, name := range sortedKeys(schemas) {
+		d.names[name] = true
+		if s, ok := schemas[name].(map[string]interface{}); ok && isObjectSchema(s) {
+			d.components[schemaKey(s)] = &schemaComponent{name: name, done: true}
+		}
+	}
+
+	d.walkPaths(paths, d.count)
+	d.walkPaths(paths, d.replace)
+
+	if len(schemas) > 0 {
+		components["schemas"] = schemas
+	}
+	o.Paths = paths
+	o.Components = components
+}
+
+// walkPaths calls fn on the request and response body schemas of every
+// operation, in a stable order
+func (d *deduplicator) walkPaths(paths map[string]interface{}, fn func(s map[string]interface{}, hint string) interface{}) {
+	for _, path := range sortedKeys(paths) {
+		methods, _ := paths[path].(map[string]interface{})
+		for _, method := range sortedKeys(methods) {
+			op, _ := methods[method].(map[string]interface{})
+			name := pascalCase(method + " " + path)
+
+			if body, ok := op["requestBody"].(map[string]interface{}); ok {
+				d.walkContent(body, name+"Request", fn)
+			}
+
+			responses, _ := op["responses"].(map[string]interface{})
+			for _, status := range sortedKeys(responses) {
+				if res, ok := responses[status].(map[string]interface{}); ok {
+					d.walkContent(res, name+status+"Response", fn)
+				}
+			}
+		}
+	}
+}
+
+func (d *deduplicator) walkContent(m map[string]interface{}, hint string, fn func(s map[string]interface{}, hint string) interface{}) {
+	content, _ := m["content"].(map[string]interface{})
+	for _, ct := range sortedKeys(content) {
+		c, _ := content[ct].(map[string]interface{})
+		if s, ok := c["schema"].(map[string]interface{}); ok {
+			c["schema"] = fn(s, hint)
+		}
+	}
+}
+
+// count records how often each object schema appears. the children of a
+// schema that is already a component, or was seen before, are not counted
+// again, as they only appear once the schema is replaced by a $ref
+func (d *deduplicator) count(s map[string]interface{}, hint string) interface{} {
+	if isObjectSchema(s) {
+		key := schemaKey(s)
+		c := d.components[key]
+		if c == nil {
+			c = &schemaComponent{}
+			d.components[key] = c
+		}
+		c.count++
+		if c.name == "" {
+			c.name, _ = s["title"].(string)
+		}
+		if c.done || c.count > 1 {
+			return s
+		}
+	}
+
+	walkChildren(s, hint, func(s map[string]interface{}, hint string) interface{} {
+		return d.count(s, hint)
+	})
+	return s
+}
+
+// replace swaps shared object schemas for a $ref, adding them to the
+// components the first time they are seen
+func (d *deduplicator) replace(s map[string]interface{}, hint string) interface{} {
+	if !isObjectSchema(s) {
+		walkChildren(s, hint, d.replace)
+		return s
+	}
+
+	c := d.components[schemaKey(s)]
+	_, titled := s["title"]
+	if !c.done && c.count < 2 && !titled {
+		walkChildren(s, hint, d.replace)
+		return s
+	}
+
+	if !c.done {
+		c.done = true
+		c.name = d.uniqueName(c.name, hint)
+		walkChildren(s, hint, d.replace)
+		d.schemas[c.name] = s
+	}
+
+	return map[string]interface{}{
+		"$ref": "#/components/schemas/" + c.name,
+	}
+}
+
+func (d *deduplicator) uniqueName(name, hint string) string {
+	if name == "" {
+		name = hint
+	}
+	unique := name
+	for i := 2; d.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	d.names[unique] = true
+	return unique
+}
+
+// walkChildren calls fn on every schema nested directly in s and replaces it
+// with the result
+func walkChildren(s map[string]interface{}, hint string, fn func(s map[string]interface{}, hint string) interface{}) {
+	if props, ok := s["properties"].(map[string]interface{}); ok {
+		for _, k := range sortedKeys(props) {
+			if p, ok := props[k].(map[string]interface{}); ok {
+				props[k] = fn(p, pascalCase(k))
+			}
+		}
+	}
+
+	for _, k := range []string{"items", "additionalProperties"} {
+		if c, ok := s[k].(map[string]interface{}); ok {
+			s[k] = fn(c, hint+"Item")
+		}
+	}
+
+	for _, k := range []string{"oneOf", "anyOf", "allOf"} {
+		alts, _ := s[k].([]interface{})
+		for i, alt := range alts {
+			if alt, ok := alt.(map[string]interface{}); ok {
+				alts[i] = fn(alt, fmt.Sprintf("%s%d", hint, i+1))
+			}
+		}
+	}
+}
+
+func isObjectSchema(s map[string]interface{}) bool {
+	props, _ := s["properties"].(map[string]interface{})
+	return s["type"] == "object" && len(props) > 0
+}
+
+// schemaKey returns a string that is equal for schemas with the same
+// structure, ignoring examples and titles
+func schemaKey(s map[string]interface{}) string {
+	b, _ := json.Marshal(canonicalSchema(s))
+	return string(b)
+}
+
+func canonicalSchema(s interface{}) interface{} {
+	m, ok := s.(map[string]interface{})
+	if !ok {
+		return s
+	}
+
+	c := map[string]interface{}{}
+	for k, v := range m {
+		switch k {
+		case "example", "examples", "title", "description":
+		case "properties":
+			props, _ := v.(map[string]interface{})
+			p := map[string]interface{}{}
+			for pk, pv := range props {
+				p[pk] = canonicalSchema(pv)
+			}
+			c[k] = p
+		case "items", "additionalProperties":
+			c[k] = canonicalSchema(v)
+		case "oneOf", "anyOf", "allOf":
+			alts, _ := v.([]interface{})
+			a := []interface{}{}
+			for _, alt := range alts {
+				a = append(a, canonicalSchema(alt))
+			}
+			c[k] = a
+		default:
+			c[k] = v
+		}
+	}
+	return c
+}
+
+// normalize converts typed values such as RequestBody into plain maps and
+// slices by round tripping them through yaml
+func normalize(v interface{}) interface{} {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var n interface{}
+	if err := yaml.Unmarshal(b, &n); err != nil {
+		return v
+	}
+	return n
+}
+
+var matchNonAlphanumeric = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+func pascalCase(s string) string {
+	words := matchNonAlphanumeric.Split(s, -1)
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
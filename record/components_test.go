@@ -0,0 +1,103 @@
+package autodoc
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDeduplicateSchemas(t *testing.T) {
+	user := func(id int) map[string]interface{} {
+		return getJSONSchema([]byte(fmt.Sprintf(`{"user":{"id":%d,"name":"foo"}}`, id)))
+	}
+	operation := func(schema map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"get": map[string]interface{}{
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{"schema": schema},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	o := OpenAPI{
+		OpenAPIConfig: OpenAPIConfig{
+			Components: map[string]interface{}{
+				"schemas": map[string]interface{}{
+					"Error": map[string]interface{}{"type": "object"},
+				},
+			},
+		},
+		Paths: map[string]interface{}{
+			"/users/{id}": operation(user(1)),
+			"/me":         operation(user(2)),
+		},
+	}
+	o.DeduplicateSchemas()
+
+	schemas := o.Components["schemas"].(map[string]interface{})
+	if _, ok := schemas["Error"]; !ok {
+		t.Errorf("existing component was dropped: %v", schemas)
+	}
+
+	want := map[string]interface{}{"$ref": "#/components/schemas/GetMe200Response"}
+	for _, path := range []string{"/users/{id}", "/me"} {
+		got := o.Paths[path].(map[string]interface{})["get"].(map[string]interface{})["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"]
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s schema = %v, want %v", path, got, want)
+		}
+	}
+
+	// the nested schema only appears in the shared component
+	got := schemas["GetMe200Response"].(map[string]interface{})["properties"].(map[string]interface{})["user"].(map[string]interface{})
+	if got["type"] != "object" {
+		t.Errorf("nested schema = %v, want it inline", got)
+	}
+	if _, ok := schemas["User"]; ok {
+		t.Errorf("nested schema was hoisted: %v", schemas)
+	}
+}
+
+func TestDeduplicateSchemaNames(t *testing.T) {
+	re := Recorder{Path: "/users", Method: "post"}
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"foo"}`))
+	}
+	req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"name":"foo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	re.Record(h, RecordOptions{
+		UseAsRequestExample: true,
+		RequestSchemaName:   "CreateUser",
+		ResponseSchemaName:  "User",
+	})(httptest.NewRecorder(), req)
+
+	o := re.OpenAPI()
+	o.DeduplicateSchemas()
+
+	op := o.Paths["/users"].(map[string]interface{})["post"].(map[string]interface{})
+	schema := func(m interface{}) interface{} {
+		return m.(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"]
+	}
+	if got, want := schema(op["requestBody"]), map[string]interface{}{"$ref": "#/components/schemas/CreateUser"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("request schema = %v, want %v", got, want)
+	}
+	if got, want := schema(op["responses"].(map[string]interface{})["200"]), map[string]interface{}{"$ref": "#/components/schemas/User"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("response schema = %v, want %v", got, want)
+	}
+
+	schemas := o.Components["schemas"].(map[string]interface{})
+	for name, prop := range map[string]string{"CreateUser": "name", "User": "id"} {
+		s, _ := schemas[name].(map[string]interface{})
+		if _, ok := s["properties"].(map[string]interface{})[prop]; !ok {
+			t.Errorf("%s = %v, want a %s property", name, s, prop)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package autodoc
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/labstack/echo/v4"
+)
+
+// echoResponseRecorder writes to both a ResponseRecorder and the original ResponseWriter
+type echoResponseRecorder struct {
+	http.ResponseWriter
+	recorder *httptest.ResponseRecorder
+}
+
+func (r *echoResponseRecorder) Write(b []byte) (int, error) {
+	r.recorder.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// WriteHeader copies the headers set so far, which echo keeps on the
+// original ResponseWriter
+func (r *echoResponseRecorder) WriteHeader(statusCode int) {
+	copyHeader(r.recorder.Header(), r.ResponseWriter.Header())
+	r.recorder.WriteHeader(statusCode)
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *echoResponseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// RecordEcho records the requests handled by an echo handler. an error
+// returned by the handler is passed to the echo error handler, so that the
+// error response the client gets is recorded, and nil is returned so that the
+// error is handled once. Path and Method are taken from the matched route
+// when they are empty
+func (re *Recorder) RecordEcho(h echo.HandlerFunc, opts ...RecordOptions) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		res := c.Response()
+		rec := &echoResponseRecorder{
+			ResponseWriter: res.Writer,
+			recorder:       httptest.NewRecorder(),
+		}
+		res.Writer = rec
+		defer func() { res.Writer = rec.ResponseWriter }()
+
+		re.setRoute(c.Request().Method, c.Path())
+
+		req := c.Request().Clone(context.Background())
+		if req.Body != nil {
+			body, _ := ioutil.ReadAll(req.Body)
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+			c.Request().Body = ioutil.NopCloser(bytes.NewBuffer(body))
+		}
+		if path := routePath(c.Path(), req.URL.Path, echoParams(c)); path != req.URL.Path {
+			req.URL.Path = path
+			req.URL.RawPath = ""
+		}
+
+		if err := h(c); err != nil {
+			c.Echo().HTTPErrorHandler(err, c)
+		}
+		if !res.Committed {
+			copyHeader(rec.recorder.Header(), rec.ResponseWriter.Header())
+		}
+
+		re.record(req, rec.recorder.Result(), opts...)
+		return nil
+	}
+}
+
+// echoParams returns the path params of the matched route. echo names the
+// wildcard param *
+func echoParams(c echo.Context) map[string]string {
+	params := map[string]string{}
+	values := c.ParamValues()
+	for i, name := range c.ParamNames() {
+		if i >= len(values) {
+			break
+		}
+		if name == "*" {
+			name = wildcardParam
+		}
+		params[name] = values[i]
+	}
+	return params
+}
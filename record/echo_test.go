@@ -0,0 +1,98 @@
+package autodoc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRecordEcho(t *testing.T) {
+	re := &Recorder{}
+	e := echo.New()
+	handled := 0
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		handled++
+		c.JSON(http.StatusTeapot, map[string]string{"code": "E_CUSTOM"})
+	}
+	e.GET("/users/:id", re.RecordEcho(func(c echo.Context) error {
+		if c.Param("id") == "0" {
+			return echo.NewHTTPError(http.StatusNotFound, "user not found")
+		}
+		c.Response().Header().Set("X-Request-Id", "abc")
+		return c.JSON(http.StatusOK, map[string]string{"id": c.Param("id")})
+	}))
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if w.Header().Get("X-Request-Id") != "abc" {
+		t.Errorf("response headers = %v, want X-Request-Id", w.Header())
+	}
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/0", nil))
+	if handled != 1 || w.Code != http.StatusTeapot {
+		t.Errorf("error handled %d times with status %d, want once with %d", handled, w.Code, http.StatusTeapot)
+	}
+
+	if re.Path != "/users/{id}" || re.Method != "get" {
+		t.Errorf("recorder = %s %s, want get /users/{id}", re.Method, re.Path)
+	}
+	if len(re.Records) != 2 {
+		t.Fatalf("records = %d, want 2", len(re.Records))
+	}
+
+	res := re.Records[0].Response
+	if res.Status != http.StatusOK || string(res.Content.Text) != "{\"id\":\"1\"}\n" {
+		t.Errorf("response = %d %s", res.Status, res.Content.Text)
+	}
+	found := false
+	for _, h := range res.Headers {
+		found = found || (h.Name == "X-Request-Id" && h.Value == "abc")
+	}
+	if !found {
+		t.Errorf("headers = %v, want X-Request-Id", res.Headers)
+	}
+	res = re.Records[1].Response
+	if res.Status != http.StatusTeapot || string(res.Content.Text) != w.Body.String() {
+		t.Errorf("error response = %d %s, want %d %s", res.Status, res.Content.Text, http.StatusTeapot, w.Body)
+	}
+
+	// a context built in a test only has its params set
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.SetPath("/users/:id")
+	c.SetParamNames("id")
+	c.SetParamValues("2")
+	if err := re.RecordEcho(func(c echo.Context) error { return c.NoContent(http.StatusNoContent) })(c); err != nil {
+		t.Fatal(err)
+	}
+	if got := re.Records[2].Request.URL; got != "/users/2" {
+		t.Errorf("url = %s, want /users/2", got)
+	}
+}
+
+func TestRecordEchoConcurrent(t *testing.T) {
+	t.Parallel()
+	re := &Recorder{}
+	e := echo.New()
+	e.GET("/users/:id", re.RecordEcho(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	const n = 50
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/"+strconv.Itoa(i), nil))
+		}(i)
+	}
+	wg.Wait()
+
+	if re.Path != "/users/{id}" || re.Route != "/users/:id" || len(re.Records) != n {
+		t.Errorf("recorder = %s (%s) with %d records, want /users/{id} (/users/:id) with %d", re.Path, re.Route, len(re.Records), n)
+	}
+}
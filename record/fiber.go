@@ -0,0 +1,97 @@
+package autodoc
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// RecordFiber records the requests handled by a fiber handler. fiber
+// doesn't use net/http, so the fasthttp request and response are converted
+// before they are recorded. an error returned by the handler is passed to the
+// app's error handler, so that the error response the client gets is
+// recorded, and nil is returned so that the error is handled once. Path and
+// Method are taken from the matched route when they are empty
+func (re *Recorder) RecordFiber(h fiber.Handler, opts ...RecordOptions) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		route := c.Route()
+		re.setRoute(c.Method(), route.Path)
+
+		req, err := fiberRequest(&c.Context().Request)
+		if err != nil {
+			return err
+		}
+		if path := routePath(route.Path, req.URL.Path, fiberParams(c, route)); path != req.URL.Path {
+			req.URL.Path = path
+			req.URL.RawPath = ""
+		}
+
+		if err := h(c); err != nil {
+			// like fiber does when the error handler fails
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		re.record(req, fiberResponse(&c.Context().Response), opts...)
+		return nil
+	}
+}
+
+// fiberParams returns the path params of the matched route. fiber names the
+// wildcard param *1
+func fiberParams(c *fiber.Ctx, route *fiber.Route) map[string]string {
+	params := map[string]string{}
+	for _, name := range route.Params {
+		key := name
+		if strings.HasPrefix(name, "*") {
+			key = wildcardParam
+		}
+		params[key] = c.Params(name)
+	}
+	return params
+}
+
+// fiberRequest converts a fasthttp request to the net/http form that recorders
+// take. fasthttp reuses its buffers once the handler returns, so everything
+// is copied
+func fiberRequest(r *fasthttp.Request) (*http.Request, error) {
+	body := append([]byte{}, r.Body()...)
+	req, err := http.NewRequest(string(r.Header.Method()), string(r.RequestURI()), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Host = string(r.Host())
+	r.Header.VisitAll(func(k, v []byte) {
+		req.Header.Add(string(k), string(v))
+	})
+	return req, nil
+}
+
+// fiberResponse converts a fasthttp response to the net/http form that
+// recorders take
+func fiberResponse(r *fasthttp.Response) *http.Response {
+	body := append([]byte{}, r.Body()...)
+	header := http.Header{}
+	r.Header.VisitAll(func(k, v []byte) {
+		header.Add(string(k), string(v))
+	})
+
+	status := r.StatusCode()
+	return &http.Response{
+		Status:        fmt.Sprintf("%03d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+}
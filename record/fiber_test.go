@@ -0,0 +1,107 @@
+package autodoc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRecordFiber(t *testing.T) {
+	re := &Recorder{}
+	handled := 0
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			handled++
+			return c.Status(http.StatusTeapot).JSON(fiber.Map{"code": "E_CUSTOM"})
+		},
+	})
+	app.Post("/users/:id", re.RecordFiber(func(c *fiber.Ctx) error {
+		if c.Params("id") == "0" {
+			return fiber.NewError(http.StatusNotFound, "user not found")
+		}
+		c.Set("X-Request-Id", "abc")
+		return c.Status(http.StatusCreated).JSON(fiber.Map{"id": c.Params("id")})
+	}, RecordOptions{UseAsRequestExample: true}))
+
+	var body []byte
+	for _, id := range []string{"1", "0"} {
+		req := httptest.NewRequest(http.MethodPost, "/users/"+id+"?notify=true", strings.NewReader(`{"name":"foo"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id == "1" && res.Header.Get("X-Request-Id") != "abc" {
+			t.Errorf("response headers = %v, want X-Request-Id", res.Header)
+		}
+		if id == "0" {
+			body, _ = ioutil.ReadAll(res.Body)
+			if handled != 1 || res.StatusCode != http.StatusTeapot {
+				t.Errorf("error handled %d times with status %d, want once with %d", handled, res.StatusCode, http.StatusTeapot)
+			}
+		}
+	}
+
+	if re.Path != "/users/{id}" || re.Method != "post" {
+		t.Errorf("recorder = %s %s, want post /users/{id}", re.Method, re.Path)
+	}
+	if len(re.Records) != 2 {
+		t.Fatalf("records = %d, want 2", len(re.Records))
+	}
+
+	rec := re.Records[0]
+	if rec.Request.URL != "/users/1?notify=true" || rec.Request.PostData.Text != `{"name":"foo"}` {
+		t.Errorf("request = %s %s", rec.Request.URL, rec.Request.PostData.Text)
+	}
+	if rec.Response.Status != http.StatusCreated || string(rec.Response.Content.Text) != `{"id":"1"}` {
+		t.Errorf("response = %d %s", rec.Response.Status, rec.Response.Content.Text)
+	}
+	found := false
+	for _, h := range rec.Response.Headers {
+		found = found || (h.Name == "X-Request-Id" && h.Value == "abc")
+	}
+	if !found {
+		t.Errorf("headers = %v, want X-Request-Id", rec.Response.Headers)
+	}
+	res := re.Records[1].Response
+	if res.Status != http.StatusTeapot || string(res.Content.Text) != string(body) {
+		t.Errorf("error response = %d %s, want %d %s", res.Status, res.Content.Text, http.StatusTeapot, body)
+	}
+
+	op := re.OpenAPI().Paths["/users/{id}"].(map[string]interface{})["post"].(map[string]interface{})
+	if _, ok := op["requestBody"]; !ok {
+		t.Errorf("operation = %v, want a requestBody", op)
+	}
+}
+
+func TestRecordFiberConcurrent(t *testing.T) {
+	t.Parallel()
+	re := &Recorder{}
+	app := fiber.New()
+	app.Get("/users/:id", re.RecordFiber(func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusNoContent)
+	}))
+
+	const n = 20
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/users/"+strconv.Itoa(i), nil)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if re.Path != "/users/{id}" || re.Route != "/users/:id" || len(re.Records) != n {
+		t.Errorf("recorder = %s (%s) with %d records, want /users/{id} (/users/:id) with %d", re.Path, re.Route, len(re.Records), n)
+	}
+}
@@ -17,31 +17,57 @@ type ginResponseRecorder struct {
 	gin.ResponseWriter
 	recorder     *httptest.ResponseRecorder
 	closeChannel chan bool
+	status       int
 }
 
 func (r *ginResponseRecorder) Header() http.Header {
-	return r.recorder.Header()
+	return r.ResponseWriter.Header()
 }
 
 func (r *ginResponseRecorder) Write(b []byte) (int, error) {
+	r.writeHeader()
 	r.recorder.Write(b)
 	return r.ResponseWriter.Write(b)
 }
 
+// WriteHeader only stores the status, like gin does, so that headers set
+// after c.Status are still recorded
 func (r *ginResponseRecorder) WriteHeader(statusCode int) {
 	// DO NOT SET IF -1
 	if statusCode == -1 {
 		return
 	}
 
-	r.recorder.WriteHeader(statusCode)
+	r.status = statusCode
 	r.ResponseWriter.WriteHeader(statusCode)
 }
 
+// writeHeader copies the headers set so far and writes the stored status to
+// the recorder
+func (r *ginResponseRecorder) writeHeader() {
+	copyHeader(r.recorder.Header(), r.ResponseWriter.Header())
+	if r.status != 0 {
+		r.recorder.WriteHeader(r.status)
+	}
+}
+
+// result returns the recorded response, flushing the status if nothing was
+// written
+func (r *ginResponseRecorder) result() *http.Response {
+	r.writeHeader()
+	return r.recorder.Result()
+}
+
 func (r *ginResponseRecorder) CloseNotify() <-chan bool {
 	return r.closeChannel
 }
 
+func copyHeader(dst, src http.Header) {
+	for k, v := range src {
+		dst[k] = append([]string{}, v...)
+	}
+}
+
 func createGinResponseRecorder(w gin.ResponseWriter) *ginResponseRecorder {
 	return &ginResponseRecorder{
 		ResponseWriter: w,
@@ -50,11 +76,11 @@ func createGinResponseRecorder(w gin.ResponseWriter) *ginResponseRecorder {
 	}
 }
 
-func createTestGinContext(c *gin.Context) (*gin.Context, *httptest.ResponseRecorder) {
+func createTestGinContext(c *gin.Context) (*gin.Context, *ginResponseRecorder) {
 	gin.SetMode(gin.TestMode)
 	rec := createGinResponseRecorder(c.Writer)
 	c.Writer = rec
-	return c, rec.recorder
+	return c, rec
 }
 
 func (re *Recorder) RecordGin(h gin.HandlerFunc, opts ...RecordOptions) gin.HandlerFunc {
@@ -78,6 +104,33 @@ func (re *Recorder) RecordGin(h gin.HandlerFunc, opts ...RecordOptions) gin.Hand
 		}
 		h(c)
 
-		re.record(req, rec.Result(), opts...)
+		re.record(req, rec.result(), opts...)
+	}
+}
+
+// GinMiddleware records every request handled by a gin engine with the
+// registry's recorder for the matched route, e.g. /users/:id is recorded as
+// /users/{id}. requests that match no route are not recorded
+func GinMiddleware(reg *Registry, opts ...RecordOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.FullPath()
+		if path == "" {
+			c.Next()
+			return
+		}
+
+		// unlike createTestGinContext, this leaves gin's global mode alone, as
+		// the middleware may run on a live engine
+		rec := createGinResponseRecorder(c.Writer)
+		c.Writer = rec
+		req := c.Request.Clone(context.Background())
+		if req.Body != nil {
+			body, _ := ioutil.ReadAll(req.Body)
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+			c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+		}
+		c.Next()
+
+		reg.Recorder(c.Request.Method, path).record(req, rec.result(), opts...)
 	}
 }
@@ -4,7 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/martian/har"
 )
@@ -31,6 +41,9 @@ func getType(i interface{}) map[string]interface{} {
 			"type":    "string",
 			"example": i,
 		}
+		if f := getStringFormat(i); f != "" {
+			m["format"] = f
+		}
 	case bool:
 		m = map[string]interface{}{
 			"type":    "boolean",
@@ -41,20 +54,30 @@ func getType(i interface{}) map[string]interface{} {
 			"type": "object",
 		}
 		p := map[string]interface{}{}
+		required := []string{}
 		for k, v := range i {
 			p[k] = getType(v)
+			required = append(required, k)
 		}
 		m["properties"] = p
+		if len(required) > 0 {
+			sort.Strings(required)
+			m["required"] = required
+		}
 	case []interface{}:
 		m = map[string]interface{}{
 			"type": "array",
 		}
-		if len(i) > 0 {
-			m["items"] = getType(i[0])
+		var items map[string]interface{}
+		for _, v := range i {
+			items = mergeItems(items, getType(v))
+		}
+		if items != nil {
+			m["items"] = items
 		}
 	case nil:
 		m = map[string]interface{}{
-			"example": nil,
+			"nullable": true,
 		}
 	default:
 		panic(fmt.Sprintf("unexpected type %T %#v", i, i))
@@ -62,30 +85,367 @@ func getType(i interface{}) map[string]interface{} {
 	return m
 }
 
-func getContentType(headers []har.Header) string {
+var (
+	matchUUID  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	matchEmail = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// getStringFormat returns the OpenAPI format of a string value, or an empty
+// string if it has no recognised format
+func getStringFormat(s string) string {
+	if _, err := time.Parse(time.RFC3339, s); err == nil {
+		return "date-time"
+	}
+	if _, err := time.Parse("2006-01-02", s); err == nil {
+		return "date"
+	}
+	if matchUUID.MatchString(s) {
+		return "uuid"
+	}
+	if matchEmail.MatchString(s) {
+		return "email"
+	}
+	if ip := net.ParseIP(s); ip != nil {
+		if ip.To4() != nil {
+			return "ipv4"
+		}
+		return "ipv6"
+	}
+	if u, err := url.Parse(s); err == nil && u.Scheme != "" && u.Host != "" {
+		return "uri"
+	}
+	return ""
+}
+
+// mergeSchema unions two inferred schemas so that any field seen in either
+// of them ends up in the result. the first example seen is kept.
+func mergeSchema(a, b map[string]interface{}) map[string]interface{} {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	if alts, ok := b["oneOf"].([]interface{}); ok {
+		for _, alt := range alts {
+			alt, _ := alt.(map[string]interface{})
+			a = mergeSchema(a, alt)
+		}
+		return a
+	}
+	if _, ok := a["oneOf"]; ok {
+		return mergeOneOf(a, b)
+	}
+
+	ta, _ := a["type"].(string)
+	tb, _ := b["type"].(string)
+	switch {
+	case ta == "":
+		return copySchema(b, a)
+	case tb == "":
+		return copySchema(a, b)
+	case ta == "integer" && tb == "number", ta == "number" && tb == "integer":
+		m := copySchema(a, b)
+		m["type"] = "number"
+		return m
+	case ta != tb:
+		return map[string]interface{}{
+			"oneOf": []interface{}{a, b},
+		}
+	}
+
+	m := copySchema(a, b)
+	// a format is only kept if every example matches it
+	if a["format"] != b["format"] {
+		delete(m, "format")
+	}
+	switch ta {
+	case "object":
+		pa, _ := a["properties"].(map[string]interface{})
+		pb, _ := b["properties"].(map[string]interface{})
+		m["properties"] = mergeProperties(pa, pb)
+		// only fields present in every example are required
+		required := intersect(getRequired(a), getRequired(b))
+		delete(m, "required")
+		if len(required) > 0 {
+			m["required"] = required
+		}
+	case "array":
+		ia, _ := a["items"].(map[string]interface{})
+		ib, _ := b["items"].(map[string]interface{})
+		if items := mergeItems(ia, ib); items != nil {
+			m["items"] = items
+		}
+	}
+	return m
+}
+
+// mergeOneOf merges b into the first alternative of a that it is compatible
+// with, or adds it as a new alternative
+func mergeOneOf(a, b map[string]interface{}) map[string]interface{} {
+	alts, _ := a["oneOf"].([]interface{})
+	merged := make([]interface{}, len(alts))
+	copy(merged, alts)
+
+	for i, alt := range merged {
+		alt, ok := alt.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if compatible(alt, b) {
+			merged[i] = mergeSchema(alt, b)
+			return map[string]interface{}{"oneOf": merged}
+		}
+	}
+	return map[string]interface{}{"oneOf": append(merged, b)}
+}
+
+// mergeItems merges the schemas of array elements. unlike mergeSchema,
+// objects whose shared properties disagree on type are kept apart as oneOf
+// alternatives instead of being merged into one object
+func mergeItems(a, b map[string]interface{}) map[string]interface{} {
+	if a == nil || b == nil {
+		return mergeSchema(a, b)
+	}
+	if alts, ok := b["oneOf"].([]interface{}); ok {
+		for _, alt := range alts {
+			alt, _ := alt.(map[string]interface{})
+			a = mergeItems(a, alt)
+		}
+		return a
+	}
+	if _, ok := a["oneOf"]; !ok && !compatible(a, b) {
+		return map[string]interface{}{
+			"oneOf": []interface{}{a, b},
+		}
+	}
+	return mergeSchema(a, b)
+}
+
+// compatible reports whether two schemas can be merged into one without
+// losing type information
+func compatible(a, b map[string]interface{}) bool {
+	ta, _ := a["type"].(string)
+	tb, _ := b["type"].(string)
+	switch {
+	case ta == "" || tb == "":
+		return true
+	case (ta == "integer" || ta == "number") && (tb == "integer" || tb == "number"):
+		return true
+	case ta != tb:
+		return false
+	case ta != "object":
+		return true
+	}
+
+	pa, _ := a["properties"].(map[string]interface{})
+	pb, _ := b["properties"].(map[string]interface{})
+	for k, va := range pa {
+		vb, ok := pb[k].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		va, _ := va.(map[string]interface{})
+		if !compatible(va, vb) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeProperties merges the property schemas of two objects
+func mergeProperties(a, b map[string]interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		vb, _ := v.(map[string]interface{})
+		va, _ := m[k].(map[string]interface{})
+		m[k] = mergeSchema(va, vb)
+	}
+	return m
+}
+
+// copySchema returns a shallow copy of a, filling in the example from b if a
+// has none. the copy is nullable if either schema is
+func copySchema(a, b map[string]interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	for k, v := range a {
+		m[k] = v
+	}
+	if m["example"] == nil && b["example"] != nil {
+		m["example"] = b["example"]
+	}
+	if b["nullable"] == true {
+		m["nullable"] = true
+	}
+	return m
+}
+
+func getRequired(m map[string]interface{}) []string {
+	switch r := m["required"].(type) {
+	case []string:
+		return r
+	case []interface{}:
+		// schemas read back from a record file
+		s := []string{}
+		for _, v := range r {
+			if v, ok := v.(string); ok {
+				s = append(s, v)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
+// intersect returns the sorted values present in both a and b
+func intersect(a, b []string) []string {
+	in := map[string]bool{}
+	for _, v := range a {
+		in[v] = true
+	}
+	r := []string{}
+	for _, v := range b {
+		if in[v] {
+			r = append(r, v)
+		}
+	}
+	sort.Strings(r)
+	return r
+}
+
+// getContentType returns the media type of a body without its parameters,
+// e.g. application/json for "application/json; charset=utf-8". if there is no
+// Content-Type header it is guessed from the body
+func getContentType(headers []har.Header, body []byte) string {
 	for _, h := range headers {
 		if strings.ToLower(h.Name) == "content-type" && h.Value != "" {
-			return h.Value
+			mt, _, err := mime.ParseMediaType(h.Value)
+			if err != nil {
+				return strings.ToLower(strings.TrimSpace(strings.Split(h.Value, ";")[0]))
+			}
+			return mt
 		}
 	}
-	return "application/json"
+
+	if len(bytes.TrimSpace(body)) == 0 || json.Valid(body) {
+		return "application/json"
+	}
+	mt, _, _ := mime.ParseMediaType(http.DetectContentType(body))
+	return mt
 }
 
+// maxTextExample is the number of characters kept in the example of a text
+// body
+const maxTextExample = 512
+
+var binaryMediaTypes = []string{
+	"application/octet-stream",
+	"application/pdf",
+	"application/zip",
+	"application/gzip",
+	"image/",
+	"audio/",
+	"video/",
+	"font/",
+}
+
+// getBodySchema infers the schema and example of a body based on its media
+// type. binary bodies have no example
+func getBodySchema(mt string, b []byte) (map[string]interface{}, interface{}) {
+	switch {
+	case isJSON(mt):
+		return getJSONSchema(b), getJSON(b)
+	case isXML(mt):
+		return getXMLSchema(b), string(b)
+	case isBinary(mt, b):
+		return map[string]interface{}{
+			"type":   "string",
+			"format": "binary",
+		}, nil
+	default:
+		return map[string]interface{}{
+			"type": "string",
+		}, truncate(string(b))
+	}
+}
+
+// isJSON reports whether the media type holds JSON, including structured
+// syntax types such as application/problem+json
+func isJSON(mt string) bool {
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func isXML(mt string) bool {
+	return mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml")
+}
+
+func isBinary(mt string, b []byte) bool {
+	for _, t := range binaryMediaTypes {
+		if strings.HasPrefix(mt, t) {
+			return true
+		}
+	}
+	return !strings.HasPrefix(mt, "text/") && !utf8.Valid(b)
+}
+
+func truncate(s string) string {
+	r := []rune(s)
+	if len(r) <= maxTextExample {
+		return s
+	}
+	return string(r[:maxTextExample]) + "..."
+}
+
+// parseMultipart returns the text fields and the files of a multipart body
+func parseMultipart(body []byte, boundary string) ([]har.Param, []FormFile) {
+	params := []har.Param{}
+	files := []FormFile{}
+
+	r := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		p, err := r.NextPart()
+		if err != nil {
+			break
+		}
+
+		b, _ := ioutil.ReadAll(p)
+		if p.FileName() != "" {
+			files = append(files, FormFile{
+				Name:        p.FormName(),
+				Filename:    p.FileName(),
+				ContentType: p.Header.Get("Content-Type"),
+				Size:        int64(len(b)),
+			})
+			continue
+		}
+
+		params = append(params, har.Param{
+			Name:  p.FormName(),
+			Value: string(b),
+		})
+	}
+	return params, files
+}
+
+// getJSONSchema infers the schema of a JSON body of any shape. an empty or
+// invalid body yields an empty schema
 func getJSONSchema(b []byte) map[string]interface{} {
-	m := map[string]interface{}{}
-	j := map[string]interface{}{}
+	var j interface{}
 	d := json.NewDecoder(bytes.NewReader(b))
 	d.UseNumber()
-	d.Decode(&j)
-	for k, v := range j {
-		m[k] = getType(v)
+	if err := d.Decode(&j); err != nil {
+		return map[string]interface{}{}
 	}
-	return m
+	return getType(j)
 }
 
-func getJSON(b []byte) map[string]interface{} {
-	m := map[string]interface{}{}
+func getJSON(b []byte) interface{} {
+	var j interface{}
 	d := json.NewDecoder(bytes.NewReader(b))
-	d.Decode(&m)
-	return m
+	d.Decode(&j)
+	return j
 }
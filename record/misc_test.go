@@ -0,0 +1,270 @@
+package autodoc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/martian/har"
+)
+
+func TestMergeSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		bodies []string
+		want   map[string]interface{}
+	}{
+		{
+			name: "union of optional fields",
+			bodies: []string{
+				`{"id":1,"name":"foo"}`,
+				`{"id":2,"description":"bar"}`,
+			},
+			want: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id":          map[string]interface{}{"type": "integer", "example": int64(1)},
+					"name":        map[string]interface{}{"type": "string", "example": "foo"},
+					"description": map[string]interface{}{"type": "string", "example": "bar"},
+				},
+				"required": []string{"id"},
+			},
+		},
+		{
+			name: "integer and number widen to number",
+			bodies: []string{
+				`{"price":1}`,
+				`{"price":1.5}`,
+			},
+			want: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"price": map[string]interface{}{"type": "number", "example": int64(1)},
+				},
+				"required": []string{"price"},
+			},
+		},
+		{
+			name: "nested objects are merged",
+			bodies: []string{
+				`{"user":{"id":1}}`,
+				`{"user":{"email":"a@b.c"}}`,
+			},
+			want: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"user": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"id":    map[string]interface{}{"type": "integer", "example": int64(1)},
+							"email": map[string]interface{}{"type": "string", "example": "a@b.c", "format": "email"},
+						},
+					},
+				},
+				"required": []string{"user"},
+			},
+		},
+		{
+			name: "sometimes null fields are nullable",
+			bodies: []string{
+				`{"parent_id":null}`,
+				`{"parent_id":3}`,
+			},
+			want: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"parent_id": map[string]interface{}{"type": "integer", "example": int64(3), "nullable": true},
+				},
+				"required": []string{"parent_id"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]interface{}
+			for _, b := range tt.bodies {
+				got = mergeSchema(got, getJSONSchema([]byte(b)))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeSchema() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeSchemaDecodedRequired(t *testing.T) {
+	// schemas read back from a record file have their required list decoded
+	// as []interface{}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"type":"object","properties":{"id":{"type":"integer"},"name":{"type":"string"}},"required":["id","name"]}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	got := mergeSchema(decoded, getJSONSchema([]byte(`{"id":1}`)))
+	if want := []string{"id"}; !reflect.DeepEqual(got["required"], want) {
+		t.Errorf("required = %#v, want %#v", got["required"], want)
+	}
+}
+
+func TestGetStringFormat(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"2022-09-01T10:00:00Z", "date-time"},
+		{"2022-09-01T10:00:00+07:00", "date-time"},
+		{"2022-09-01", "date"},
+		{"3f2b8c1e-7a4d-4e7b-9a55-0c1d2e3f4a5b", "uuid"},
+		{"foo@example.com", "email"},
+		{"https://example.com/foo", "uri"},
+		{"127.0.0.1", "ipv4"},
+		{"::1", "ipv6"},
+		{"name-example", ""},
+		{"1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := getStringFormat(tt.value); got != tt.want {
+				t.Errorf("getStringFormat(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeSchemaFormat(t *testing.T) {
+	s := mergeSchema(getType("2022-09-01"), getType("2022-09-02"))
+	if s["format"] != "date" {
+		t.Errorf("format = %v, want date", s["format"])
+	}
+
+	s = mergeSchema(s, getType("tomorrow"))
+	if _, ok := s["format"]; ok {
+		t.Errorf("format = %v, want none", s["format"])
+	}
+}
+
+func TestGetJSONSchemaTopLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want map[string]interface{}
+	}{
+		{
+			name: "array",
+			body: `[{"id":1}]`,
+			want: map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"id": map[string]interface{}{"type": "integer", "example": int64(1)},
+					},
+					"required": []string{"id"},
+				},
+			},
+		},
+		{
+			name: "scalar",
+			body: `"ok"`,
+			want: map[string]interface{}{"type": "string", "example": "ok"},
+		},
+		{
+			name: "empty",
+			body: ``,
+			want: map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getJSONSchema([]byte(tt.body)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getJSONSchema() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTypeArrayItems(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want map[string]interface{}
+	}{
+		{
+			name: "mixed integers and floats",
+			body: `[1, 2.5]`,
+			want: map[string]interface{}{"type": "number", "example": int64(1)},
+		},
+		{
+			name: "compatible objects are merged",
+			body: `[{"kind":"a","a":1},{"kind":"b","b":true}]`,
+			want: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"kind": map[string]interface{}{"type": "string", "example": "a"},
+					"a":    map[string]interface{}{"type": "integer", "example": int64(1)},
+					"b":    map[string]interface{}{"type": "boolean", "example": true},
+				},
+				"required": []string{"kind"},
+			},
+		},
+		{
+			name: "incompatible types",
+			body: `[{"id":1},{"id":"x"},"y"]`,
+			want: map[string]interface{}{
+				"oneOf": []interface{}{
+					map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{"id": map[string]interface{}{"type": "integer", "example": int64(1)}},
+						"required":   []string{"id"},
+					},
+					map[string]interface{}{
+						"type":       "object",
+						"properties": map[string]interface{}{"id": map[string]interface{}{"type": "string", "example": "x"}},
+						"required":   []string{"id"},
+					},
+					map[string]interface{}{"type": "string", "example": "y"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getJSONSchema([]byte(tt.body))["items"]
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("items = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetContentType(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		body   string
+		want   string
+		json   bool
+	}{
+		{"charset", "application/json; charset=utf-8", `{}`, "application/json", true},
+		{"problem json", "application/problem+json", `{}`, "application/problem+json", true},
+		{"vendor json", "application/vnd.api+json", `{}`, "application/vnd.api+json", true},
+		{"multipart boundary", "multipart/form-data; boundary=foo", ``, "multipart/form-data", false},
+		{"missing json", "", `{"id":1}`, "application/json", true},
+		{"missing text", "", `hello`, "text/plain", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := []har.Header{}
+			if tt.header != "" {
+				headers = append(headers, har.Header{Name: "Content-Type", Value: tt.header})
+			}
+			got := getContentType(headers, []byte(tt.body))
+			if got != tt.want {
+				t.Errorf("getContentType() = %q, want %q", got, tt.want)
+			}
+			if isJSON(got) != tt.json {
+				t.Errorf("isJSON(%q) = %v, want %v", got, isJSON(got), tt.json)
+			}
+		})
+	}
+}
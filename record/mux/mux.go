@@ -0,0 +1,25 @@
+// Package autodocmux records the requests handled by a gorilla/mux router
+package autodocmux
+
+import (
+	"net/http"
+
+	autodoc "github.com/arpinfidel/autodoc/record"
+	"github.com/gorilla/mux"
+)
+
+// Middleware records the requests handled by a gorilla/mux router with the
+// registry's recorder for their route's path template, e.g. /users/{id}
+func Middleware(reg *autodoc.Registry, opts ...autodoc.RecordOptions) func(http.Handler) http.Handler {
+	return reg.Middleware(func(r *http.Request) string {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			return ""
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return ""
+		}
+		return path
+	}, opts...)
+}
@@ -0,0 +1,36 @@
+package autodocmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	autodoc "github.com/arpinfidel/autodoc/record"
+	"github.com/gorilla/mux"
+)
+
+func TestMiddleware(t *testing.T) {
+	reg := &autodoc.Registry{}
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	r := mux.NewRouter()
+	r.Use(Middleware(reg))
+	r.HandleFunc("/articles/{category}/{id:[0-9]+}", h).Methods(http.MethodPut)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/articles/go/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/articles/go/x", nil))
+
+	want := []string{"put /articles/{category}/{id}"}
+	if got := reg.Routes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %v, want %v", got, want)
+	}
+	if got := len(reg.Recorders()[0].Records); got != 1 {
+		t.Fatalf("records = %d, want 1", got)
+	}
+	if status := reg.Recorders()[0].Records[0].Response.Status; status != http.StatusCreated {
+		t.Errorf("status = %d, want %d", status, http.StatusCreated)
+	}
+}
@@ -3,6 +3,7 @@ package autodoc
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -15,6 +16,59 @@ type OpenAPIConfig struct {
 	Components map[string]interface{}   `yaml:"components"`
 	Security   []map[string]interface{} `yaml:"security"  `
 	Servers    []map[string]string      `yaml:"servers"   `
+
+	// the options below control the generator and are left out of the
+	// generated document
+
+	// ResponseHeaderDenylist lists response headers that are not documented.
+	// defaults to defaultResponseHeaderDenylist when empty
+	ResponseHeaderDenylist []string `yaml:"response_header_denylist,omitempty"`
+	// HeaderAllowlist, when set, limits the documented request headers to
+	// the ones listed
+	HeaderAllowlist []string `yaml:"header_allowlist,omitempty"`
+	// HeaderDenylist lists request headers that are not documented as
+	// parameters. defaults to defaultHeaderDenylist when empty
+	HeaderDenylist []string `yaml:"header_denylist,omitempty"`
+	// BasePaths are prefixes that the recorded requests may be mounted
+	// under, e.g. /api/v1. they are stripped before matching the recorder's
+	// path
+	BasePaths []string `yaml:"base_paths,omitempty"`
+}
+
+// forbiddenHeaders may not be documented as header parameters. Authorization
+// is documented as a security scheme instead
+var forbiddenHeaders = []string{
+	"Accept",
+	"Authorization",
+	"Content-Type",
+}
+
+var defaultHeaderDenylist = []string{
+	"Accept-Encoding",
+	"Accept-Language",
+	"Cache-Control",
+	"Connection",
+	"Content-Length",
+	"Cookie",
+	"Host",
+	"Origin",
+	"Pragma",
+	"Proxy-Authorization",
+	"Referer",
+	"Transfer-Encoding",
+	"User-Agent",
+	"X-Forwarded-For",
+	"X-Forwarded-Host",
+	"X-Forwarded-Proto",
+	"X-Real-Ip",
+}
+
+var defaultResponseHeaderDenylist = []string{
+	"Connection",
+	"Content-Length",
+	"Content-Type",
+	"Date",
+	"Transfer-Encoding",
 }
 
 type OpenAPIInfo struct {
@@ -26,6 +80,9 @@ type OpenAPI struct {
 	OpenAPIConfig `yaml:",inline"`
 	OpenAPI       string                 `yaml:"openapi"`
 	Paths         map[string]interface{} `yaml:"paths"`
+
+	// Warnings lists the recorded requests that couldn't be fully documented
+	Warnings []Warning `yaml:"-"`
 }
 
 type RequestBody struct {
@@ -33,20 +90,29 @@ type RequestBody struct {
 }
 
 type Content struct {
-	Schema   Schema             `yaml:"schema"`
-	Examples map[string]Example `yaml:"examples"`
+	Schema   Schema                 `yaml:"schema"`
+	Examples map[string]Example     `yaml:"examples"`
+	Encoding map[string]interface{} `yaml:"encoding,omitempty"`
 }
 
-type Schema struct {
-	Type       string      `yaml:"type"`
-	Properties interface{} `yaml:"properties"`
-}
+type Schema map[string]interface{}
 
 type Example struct {
 	Summary string      `yaml:"summary"`
 	Value   interface{} `yaml:"value"`
 }
 
+// MarshalYAML leaves the generator options out of the document
+func (o OpenAPI) MarshalYAML() (interface{}, error) {
+	type document OpenAPI
+	d := document(o)
+	d.ResponseHeaderDenylist = nil
+	d.HeaderAllowlist = nil
+	d.HeaderDenylist = nil
+	d.BasePaths = nil
+	return d, nil
+}
+
 func (o *OpenAPI) Bytes() []byte {
 	y, _ := yaml.Marshal(o)
 	return y
@@ -56,167 +122,375 @@ func (o *OpenAPI) String() string {
 	return string(o.Bytes())
 }
 
-func (re *Recorder) OpenAPI() OpenAPI {
+func (re *Recorder) OpenAPI(cfg ...OpenAPIConfig) OpenAPI {
+	re.recordsLock.RLock()
+	defer re.recordsLock.RUnlock()
+
+	config := OpenAPIConfig{}
+	if len(cfg) > 0 {
+		config = cfg[0]
+	}
+
+	names := re.exampleNames()
 	requestBody := RequestBody{}
-	reqs := []har.Request{}
-	i := 0
 	formData := []har.Param{}
-	for _, rec := range re.Records {
+	schemas := map[string]map[string]interface{}{}
+	typedSchemas := map[string]map[string]interface{}{}
+	requestSchemaName := ""
+	for i, rec := range re.Records {
 		if rec.Options.ExcludeFromOpenAPI {
 			continue
 		}
 		if !rec.Options.UseAsRequestExample {
 			continue
 		}
-		i++
-		reqs = append(reqs, *rec.Request)
 
 		req := rec.Request
+		if requestSchemaName == "" {
+			requestSchemaName = rec.Options.RequestSchemaName
+		}
 
 		if req.PostData != nil {
 			if requestBody.Content == nil {
 				requestBody.Content = map[string]Content{}
 			}
 
-			content := requestBody.Content[getContentType(req.Headers)]
-			content.Schema = Schema{
-				Type: "object",
-			}
-
+			ct := getContentType(req.Headers, []byte(req.PostData.Text))
+			content := requestBody.Content[ct]
 			if content.Examples == nil {
 				content.Examples = map[string]Example{}
 			}
 
-			if _, ok := content.Schema.Properties.(map[string]interface{}); !ok {
-				content.Schema.Properties = map[string]interface{}{}
-			}
+			exampleName := names[i]
 
-			exampleName := fmt.Sprintf("%d. %s", i, rec.Options.RequestName)
-			if rec.Options.RequestName == "" {
-				exampleName = fmt.Sprintf("%d. Example", i)
-			}
-
-			switch getContentType(req.Headers) {
-			case "application/json":
-				content.Schema.Properties = getJSONSchema([]byte(req.PostData.Text))
+			switch {
+			case isJSON(ct):
+				schemas[ct] = mergeSchema(schemas[ct], getJSONSchema([]byte(req.PostData.Text)))
 				content.Examples[exampleName] = Example{
 					Summary: rec.Options.RequestSummary,
 					Value:   getJSON([]byte(req.PostData.Text)),
 				}
-			case "application/x-www-form-urlencoded":
+			case isXML(ct):
+				schemas[ct] = mergeXMLSchema(schemas[ct], getXMLSchema([]byte(req.PostData.Text)))
+				content.Examples[exampleName] = Example{
+					Summary: rec.Options.RequestSummary,
+					Value:   req.PostData.Text,
+				}
+			case ct == "application/x-www-form-urlencoded":
 				exampleArr := []string{}
+				props := map[string]interface{}{}
+				required := []string{}
 				for _, p := range req.PostData.Params {
 					if p.Name == "" {
 						continue
 					}
 
-					content.Schema.Properties.(map[string]interface{})[p.Name] = map[string]interface{}{
+					if _, ok := props[p.Name]; !ok {
+						required = append(required, p.Name)
+					}
+					props[p.Name] = map[string]interface{}{
 						"type":    predictValueType(p.Value),
 						"example": p.Value,
 					}
 
 					exampleArr = append(exampleArr, fmt.Sprintf("%s=%s", p.Name, p.Value))
 				}
+				sort.Strings(required)
+				schemas[ct] = mergeSchema(schemas[ct], map[string]interface{}{
+					"type":       "object",
+					"properties": props,
+					"required":   required,
+				})
 
 				content.Examples[exampleName] = Example{
 					Summary: rec.Options.RequestSummary,
 					Value:   strings.Join(exampleArr, "&"),
 				}
-			case "multipart/form-data":
-			// TODO : handle file submission
-			case "text/plain":
+			case ct == "multipart/form-data":
+				props := map[string]interface{}{}
+				required := []string{}
+				example := map[string]interface{}{}
+				for _, p := range req.PostData.Params {
+					if _, ok := props[p.Name]; !ok {
+						required = append(required, p.Name)
+					}
+					props[p.Name] = map[string]interface{}{
+						"type":    predictValueType(p.Value),
+						"example": p.Value,
+					}
+					example[p.Name] = p.Value
+				}
+
+				files := map[string][]FormFile{}
+				for _, f := range rec.Files {
+					files[f.Name] = append(files[f.Name], f)
+				}
+				for name, fs := range files {
+					required = append(required, name)
+					props[name] = map[string]interface{}{
+						"type":   "string",
+						"format": "binary",
+					}
+					if len(fs) > 1 {
+						props[name] = map[string]interface{}{
+							"type":  "array",
+							"items": props[name],
+						}
+					}
+
+					if fs[0].ContentType != "" {
+						if content.Encoding == nil {
+							content.Encoding = map[string]interface{}{}
+						}
+						content.Encoding[name] = map[string]interface{}{
+							"contentType": fs[0].ContentType,
+						}
+					}
+
+					names := []string{}
+					for _, f := range fs {
+						names = append(names, fmt.Sprintf("%s (%s, %d bytes)", f.Filename, f.ContentType, f.Size))
+					}
+					example[name] = strings.Join(names, ", ")
+				}
+
+				sort.Strings(required)
+				schemas[ct] = mergeSchema(schemas[ct], map[string]interface{}{
+					"type":       "object",
+					"properties": props,
+					"required":   required,
+				})
+
+				content.Examples[exampleName] = Example{
+					Summary: rec.Options.RequestSummary,
+					Value:   example,
+				}
+			case ct == "text/plain":
+				schemas[ct] = mergeSchema(schemas[ct], getType(req.PostData.Text))
 				content.Examples[exampleName] = Example{
 					Summary: rec.Options.RequestSummary,
 					Value:   req.PostData.Text,
 				}
 			}
 
-			requestBody.Content[getContentType(req.Headers)] = content
+			if rec.RequestSchema != nil {
+				typedSchemas[ct] = rec.RequestSchema
+			}
+
+			requestBody.Content[ct] = content
 			formData = append(formData, req.PostData.Params...)
 		}
 	}
 
-	params := []map[string]interface{}{}
+	// schemas reflected from Go types take precedence over inferred ones
+	for ct, schema := range typedSchemas {
+		schemas[ct] = schema
+	}
+
+	for ct, schema := range schemas {
+		content := requestBody.Content[ct]
+		content.Schema = schema
+		if requestSchemaName != "" {
+			content.Schema = copySchema(schema, nil)
+			content.Schema["title"] = requestSchemaName
+		}
+		requestBody.Content[ct] = content
+	}
 
-	req := reqs[len(reqs)-1]
+	params, warnings := re.parameters(config, names)
+	warnings = append(warnings, re.xmlWarnings()...)
 
-	// TODO: support multiple request examples
-	recP := strings.Split(re.Path, "/")
-	reqP := strings.Split(req.URL, "?")[0]
-	reqPs := strings.Split(reqP, "/")
-	if len(recP) != len(reqPs) {
-		fmt.Println("request path does not match recorder path. skipping path parsing")
-	} else {
-		for i := range recP {
-			recP := recP[i]
-			reqP := reqPs[i]
-			if recP == reqP {
-				continue
-			}
+	responses := map[string]interface{}{}
+	responseSchemaNames := map[string]string{}
+	typedResponses := map[string]map[string]map[string]interface{}{}
+	for i, rec := range re.Records {
+		if !rec.documented() {
+			continue
+		}
 
-			params = append(params, map[string]interface{}{
-				"in":       "path",
-				"name":     strings.Trim(recP, "{}"),
-				"required": true,
-				"schema": map[string]interface{}{
-					"type": "string",
-				},
-				"example": reqP,
-			})
+		status := strconv.Itoa(rec.Response.Status)
+		res := rec.responseExample(rec.Options.ResponseDescription, names[i], config)
+		if prev, ok := responses[status].(map[string]interface{}); ok {
+			res = mergeResponse(prev, res)
 		}
-	}
+		responses[status] = res
 
-	for _, q := range req.QueryString {
-		params = append(params, map[string]interface{}{
-			"in":   "query",
-			"name": q.Name,
-			"schema": map[string]interface{}{
-				"type": "string",
-			},
-			"example": q.Value,
-		})
+		if responseSchemaNames[status] == "" {
+			responseSchemaNames[status] = rec.Options.ResponseSchemaName
+		}
+
+		if rec.ResponseSchema != nil {
+			if typedResponses[status] == nil {
+				typedResponses[status] = map[string]map[string]interface{}{}
+			}
+			ct := getContentType(rec.Response.Headers, rec.Response.Content.Text)
+			typedResponses[status][ct] = rec.ResponseSchema
+		}
 	}
 
-	for _, h := range req.Headers {
-		params = append(params, map[string]interface{}{
-			"in":       "header",
-			"name":     h.Name,
-			"required": true,
-			"schema": map[string]interface{}{
-				"type": "string",
-			},
-			"example": h.Value,
-		})
+	// as for the request body, schemas reflected from Go types take
+	// precedence over inferred ones, and only the examples are merged
+	for status, typed := range typedResponses {
+		content, _ := responses[status].(map[string]interface{})["content"].(map[string]interface{})
+		for ct, schema := range typed {
+			if c, ok := content[ct].(map[string]interface{}); ok {
+				c["schema"] = schema
+			}
+		}
 	}
 
-	responses := map[string]interface{}{}
-	for _, rec := range re.Records {
-		if rec.Options.ExcludeFromOpenAPI && !rec.Options.UseAsRequestExample {
+	for status, name := range responseSchemaNames {
+		if name == "" {
 			continue
 		}
+		content, _ := responses[status].(map[string]interface{})["content"].(map[string]interface{})
+		for _, c := range content {
+			c := c.(map[string]interface{})
+			if schema, ok := c["schema"].(map[string]interface{}); ok {
+				schema = copySchema(schema, nil)
+				schema["title"] = name
+				c["schema"] = schema
+			}
+		}
+	}
 
-		responses[strconv.Itoa(rec.Response.Status)] = rec.ResponseExample(rec.Options.ResponseDescription)
+	operation := map[string]interface{}{
+		"tags":        []string{re.Tag},
+		"description": re.APIDescription,
+		"summary":     re.APISummary,
+		"parameters":  params,
+		"responses":   responses,
+	}
+	// an operation without a recorded request example has no documented body
+	if requestBody.Content != nil {
+		operation["requestBody"] = requestBody
 	}
 
 	yml := OpenAPI{
 		OpenAPI:       "3.0.3",
 		OpenAPIConfig: OpenAPIConfig{},
 		Paths: map[string]interface{}{
-			re.Path: map[string]interface{}{
-				re.Method: map[string]interface{}{
-					"tags":        []string{re.Tag},
-					"description": re.APIDescription,
-					"summary":     re.APISummary,
-					"requestBody": requestBody,
-					"parameters":  params,
-					"responses":   responses,
-				},
+			openAPIPath(re.Path): map[string]interface{}{
+				re.Method: operation,
 			},
 		},
+		Warnings: warnings,
+	}
+
+	schemes, security := re.security()
+	if len(schemes) > 0 {
+		yml.Components = map[string]interface{}{
+			"securitySchemes": schemes,
+		}
+	}
+	if security != nil {
+		operation["security"] = security
 	}
 	return yml
 }
 
+// exampleNames returns the example name of each record, numbering the
+// documented records in order, so that a record's body, parameters and
+// response share a label
+func (re *Recorder) exampleNames() []string {
+	names := make([]string, len(re.Records))
+	n := 0
+	for i, rec := range re.Records {
+		if !rec.documented() {
+			continue
+		}
+		n++
+		names[i] = exampleName(n, rec.Options)
+	}
+	return names
+}
+
+// documented reports whether the record appears in the OpenAPI document. the
+// response of an excluded request example is still documented
+func (e *Entry) documented() bool {
+	return !e.Options.ExcludeFromOpenAPI || e.Options.UseAsRequestExample
+}
+
+// exampleName names the i-th example after the record's RequestName
+func exampleName(i int, opts *RecordOptions) string {
+	if opts.RequestName == "" {
+		return fmt.Sprintf("%d. Example", i)
+	}
+	return fmt.Sprintf("%d. %s", i, opts.RequestName)
+}
+
+// mergeResponse merges the content schemas of b into a. the first non-empty
+// description is kept
+func mergeResponse(a, b map[string]interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	for k, v := range a {
+		m[k] = v
+	}
+	if m["description"] == "" {
+		m["description"] = b["description"]
+	}
+
+	if hb, _ := b["headers"].(map[string]interface{}); len(hb) > 0 {
+		headers := map[string]interface{}{}
+		ha, _ := a["headers"].(map[string]interface{})
+		for k, v := range ha {
+			headers[k] = v
+		}
+		for k, v := range hb {
+			if _, ok := headers[k]; !ok {
+				headers[k] = v
+			}
+		}
+		m["headers"] = headers
+	}
+
+	cb, _ := b["content"].(map[string]interface{})
+	if len(cb) == 0 {
+		return m
+	}
+
+	content := map[string]interface{}{}
+	ca, _ := a["content"].(map[string]interface{})
+	for ct, v := range ca {
+		content[ct] = v
+	}
+	for ct, v := range cb {
+		vb, _ := v.(map[string]interface{})
+		va, ok := content[ct].(map[string]interface{})
+		if !ok {
+			content[ct] = vb
+			continue
+		}
+
+		sa, _ := va["schema"].(map[string]interface{})
+		sb, _ := vb["schema"].(map[string]interface{})
+		merged := map[string]interface{}{}
+		for k, v := range va {
+			merged[k] = v
+		}
+		if isXML(ct) {
+			merged["schema"] = mergeXMLSchema(sa, sb)
+		} else {
+			merged["schema"] = mergeSchema(sa, sb)
+		}
+
+		if eb, _ := vb["examples"].(map[string]interface{}); len(eb) > 0 {
+			examples := map[string]interface{}{}
+			ea, _ := va["examples"].(map[string]interface{})
+			for k, v := range ea {
+				examples[k] = v
+			}
+			for k, v := range eb {
+				examples[k] = v
+			}
+			merged["examples"] = examples
+		}
+		content[ct] = merged
+	}
+	m["content"] = content
+	return m
+}
+
 var (
 	matchNumber = regexp.MustCompile(`^\d+$|^\d+\.\d+$`)
 	matchBool   = regexp.MustCompile(`^(true|false)$`)
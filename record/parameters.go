@@ -0,0 +1,315 @@
+package autodoc
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// maxEnumValues is the largest set of recorded values documented as an enum
+const maxEnumValues = 5
+
+var (
+	// leading zeros are kept as strings, e.g. zip codes
+	matchInteger = regexp.MustCompile(`^-?(0|[1-9]\d*)$`)
+	matchDecimal = regexp.MustCompile(`^-?(0|[1-9]\d*)\.\d+$`)
+	matchToken   = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+)
+
+// parameter collects the values of a path, query or header parameter across
+// all recorded requests
+type parameter struct {
+	in       string
+	name     string
+	count    int
+	repeated bool
+	values   []string
+	examples map[string][]string
+	order    []string
+}
+
+// parameters builds the operation's parameters from every recorded request.
+// a query or header parameter is only required if every request has it.
+// requests whose path doesn't match the recorder's are reported as warnings
+func (re *Recorder) parameters(config OpenAPIConfig, names []string) ([]map[string]interface{}, []Warning) {
+	warnings := []Warning{}
+	documented := headerFilter(config)
+	params := map[string]*parameter{}
+	order := []string{}
+	add := func(in, name string, values []string, example string) {
+		key := in + ":" + name
+		p, ok := params[key]
+		if !ok {
+			p = &parameter{
+				in:       in,
+				name:     name,
+				examples: map[string][]string{},
+			}
+			params[key] = p
+			order = append(order, key)
+		}
+		if _, ok := p.examples[example]; ok {
+			return
+		}
+		p.count++
+		p.repeated = p.repeated || len(values) > 1
+		p.values = append(p.values, values...)
+		p.examples[example] = values
+		p.order = append(p.order, example)
+	}
+
+	template := re.Path
+	if re.Route != "" {
+		template = re.Route
+	}
+
+	total := 0
+	for i, rec := range re.Records {
+		if rec.Options.ExcludeFromOpenAPI {
+			continue
+		}
+		total++
+		req := rec.Request
+		example := names[i]
+
+		pathParams, ok := matchRequestPath(template, req.URL, config.BasePaths)
+		if !ok {
+			warnings = append(warnings, Warning{
+				Method:  re.Method,
+				Path:    template,
+				URL:     req.URL,
+				Message: "request path does not match recorder path. skipping path parameters",
+			})
+		}
+		for _, p := range pathParams {
+			add("path", p.name, []string{p.value}, example)
+		}
+
+		// credentials are documented as security schemes
+		query, names := groupValues(len(req.QueryString), func(i int) (string, string) {
+			return req.QueryString[i].Name, req.QueryString[i].Value
+		})
+		for _, name := range names {
+			if isAPIKeyQuery(name) {
+				continue
+			}
+			add("query", name, query[name], example)
+		}
+
+		headers, names := groupValues(len(req.Headers), func(i int) (string, string) {
+			return req.Headers[i].Name, req.Headers[i].Value
+		})
+		for _, name := range names {
+			if !documented(name) || isAPIKeyHeader(name) {
+				continue
+			}
+			add("header", name, headers[name], example)
+		}
+	}
+
+	res := []map[string]interface{}{}
+	for _, key := range order {
+		p := params[key]
+		schema := getParamSchema(p.values)
+		// repeated query keys, e.g. ?id=1&id=2
+		if p.repeated && p.in == "query" {
+			schema = map[string]interface{}{
+				"type":  "array",
+				"items": schema,
+			}
+		}
+
+		m := map[string]interface{}{
+			"in":     p.in,
+			"name":   p.name,
+			"schema": schema,
+		}
+		if schema["type"] == "array" {
+			m["style"] = "form"
+			m["explode"] = true
+		}
+		if p.in == "path" || p.count == total {
+			m["required"] = true
+		}
+		if total > 1 {
+			examples := map[string]interface{}{}
+			for _, name := range p.order {
+				examples[name] = map[string]interface{}{
+					"value": paramExample(p.examples[name], schema),
+				}
+			}
+			m["examples"] = examples
+		} else {
+			m["example"] = paramExample(p.examples[p.order[0]], schema)
+		}
+		res = append(res, m)
+	}
+	return res, warnings
+}
+
+// groupValues groups n name/value pairs by name, keeping the order in which
+// the names first appear
+func groupValues(n int, pair func(i int) (string, string)) (map[string][]string, []string) {
+	values := map[string][]string{}
+	names := []string{}
+	for i := 0; i < n; i++ {
+		name, value := pair(i)
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = append(values[name], value)
+	}
+	return values, names
+}
+
+// getParamSchema infers the schema of a parameter from all of its recorded
+// values. the narrowest type that fits every value is used, and strings only
+// get a format if every value has it. a small set of word-like values that
+// repeat across requests is documented as an enum
+func getParamSchema(values []string) map[string]interface{} {
+	typ := ""
+	for _, v := range values {
+		typ = widenParamType(typ, getParamType(v))
+	}
+	if typ == "" {
+		typ = "string"
+	}
+	m := map[string]interface{}{
+		"type": typ,
+	}
+	if typ != "string" {
+		return m
+	}
+
+	format := ""
+	for i, v := range values {
+		f := getStringFormat(v)
+		if i > 0 && f != format {
+			format = ""
+			break
+		}
+		format = f
+	}
+	if format != "" {
+		m["format"] = format
+		return m
+	}
+
+	if enum := getParamEnum(values); enum != nil {
+		m["enum"] = enum
+	}
+	return m
+}
+
+func getParamType(v string) string {
+	switch {
+	case matchInteger.MatchString(v):
+		return "integer"
+	case matchDecimal.MatchString(v):
+		return "number"
+	case matchBool.MatchString(v):
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+func widenParamType(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case (a == "integer" && b == "number") || (a == "number" && b == "integer"):
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// getParamEnum returns the distinct values as an enum, or nil if they don't
+// look like a closed set. at least two distinct values must have been seen,
+// and some of them more than once
+func getParamEnum(values []string) []interface{} {
+	seen := map[string]bool{}
+	distinct := []string{}
+	for _, v := range values {
+		if !matchToken.MatchString(v) {
+			return nil
+		}
+		if !seen[v] {
+			seen[v] = true
+			distinct = append(distinct, v)
+		}
+	}
+	if len(distinct) < 2 || len(distinct) > maxEnumValues || len(distinct) == len(values) {
+		return nil
+	}
+
+	sort.Strings(distinct)
+	enum := []interface{}{}
+	for _, v := range distinct {
+		enum = append(enum, v)
+	}
+	return enum
+}
+
+// paramExample converts the values recorded for one request to the type of
+// the parameter's schema
+func paramExample(values []string, schema map[string]interface{}) interface{} {
+	if schema["type"] == "array" {
+		items, _ := schema["items"].(map[string]interface{})
+		example := []interface{}{}
+		for _, v := range values {
+			example = append(example, paramValue(v, items))
+		}
+		return example
+	}
+	return paramValue(values[0], schema)
+}
+
+func paramValue(v string, schema map[string]interface{}) interface{} {
+	switch schema["type"] {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// headerFilter returns a function that reports whether a request header
+// should be documented as a parameter
+func headerFilter(config OpenAPIConfig) func(name string) bool {
+	denylist := config.HeaderDenylist
+	if len(denylist) == 0 {
+		denylist = defaultHeaderDenylist
+	}
+
+	denied := map[string]bool{}
+	for _, h := range denylist {
+		denied[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, h := range forbiddenHeaders {
+		denied[http.CanonicalHeaderKey(h)] = true
+	}
+	allowed := map[string]bool{}
+	for _, h := range config.HeaderAllowlist {
+		allowed[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return func(name string) bool {
+		name = http.CanonicalHeaderKey(name)
+		if denied[name] {
+			return false
+		}
+		return len(allowed) == 0 || allowed[name]
+	}
+}
@@ -0,0 +1,188 @@
+package autodoc
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Warning describes a recorded request that couldn't be fully documented
+type Warning struct {
+	Method string
+	// Path is the recorder's path template
+	Path string
+	// URL is the recorded request URL
+	URL     string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s %s: %s: %s", strings.ToUpper(w.Method), w.Path, w.URL, w.Message)
+}
+
+// wildcardParam names an unnamed wildcard, e.g. echo's /files/*
+const wildcardParam = "wildcard"
+
+// pathParam is the value of a path parameter in a recorded request
+type pathParam struct {
+	name  string
+	value string
+}
+
+// matchRequestPath matches the path of a recorded request URL against a path
+// template. the URL may be absolute, and may be mounted under one of
+// basePaths
+func matchRequestPath(template, rawURL string, basePaths []string) ([]pathParam, bool) {
+	path := requestPath(rawURL)
+	if params, ok := matchTemplate(template, path); ok {
+		return params, true
+	}
+
+	for _, base := range basePaths {
+		base = strings.Trim(base, "/")
+		if base == "" {
+			continue
+		}
+		base = "/" + base
+
+		rest := ""
+		switch {
+		case path == base:
+			rest = "/"
+		case strings.HasPrefix(path, base+"/"):
+			rest = path[len(base):]
+		default:
+			continue
+		}
+		if params, ok := matchTemplate(template, rest); ok {
+			return params, true
+		}
+	}
+	return nil, false
+}
+
+// requestPath returns the escaped path of a request URL without its scheme,
+// host and query
+func requestPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return strings.SplitN(rawURL, "?", 2)[0]
+	}
+	return u.EscapedPath()
+}
+
+// matchTemplate matches path against a template that uses {id}, or gin
+// style :id and *wildcard parameters. trailing slashes are ignored
+func matchTemplate(template, path string) ([]pathParam, bool) {
+	tmpl := pathSegments(template)
+	segs := pathSegments(path)
+
+	params := []pathParam{}
+	for i, t := range tmpl {
+		name, wildcard := templateParam(t)
+		if wildcard {
+			value := ""
+			if i < len(segs) {
+				value = strings.Join(segs[i:], "/")
+			}
+			params = append(params, pathParam{name: name, value: unescapePath(value)})
+			return params, true
+		}
+		if i >= len(segs) {
+			return nil, false
+		}
+
+		switch {
+		case name != "":
+			if segs[i] == "" {
+				return nil, false
+			}
+			params = append(params, pathParam{name: name, value: unescapePath(segs[i])})
+		case t != segs[i]:
+			return nil, false
+		}
+	}
+	if len(tmpl) != len(segs) {
+		return nil, false
+	}
+	return params, true
+}
+
+// openAPIPath converts the gin style parameters of a path template to the
+// {id} form used by OpenAPI
+func openAPIPath(template string) string {
+	segs := strings.Split(template, "/")
+	for i, s := range segs {
+		if name, _ := templateParam(s); name != "" {
+			segs[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// routePaths returns the OpenAPI path of a router's route template, and the
+// template itself if it differs
+func routePaths(route string) (string, string) {
+	path := openAPIPath(route)
+	if path == route {
+		return path, ""
+	}
+	return path, route
+}
+
+// routePath returns the request path if it matches the route template, and
+// the template filled in with params otherwise. contexts built in tests often
+// only have their params set. params are keyed by name, with wildcardParam
+// for an unnamed wildcard
+func routePath(template, path string, params map[string]string) string {
+	if _, ok := matchTemplate(template, path); ok || template == "" {
+		return path
+	}
+	return fillPath(template, params)
+}
+
+// fillPath replaces the parameters of a path template with their values
+func fillPath(template string, params map[string]string) string {
+	segs := strings.Split(template, "/")
+	for i, s := range segs {
+		if name, _ := templateParam(s); name != "" {
+			segs[i] = params[name]
+		}
+	}
+	return strings.Join(segs, "/")
+}
+
+// templateParam returns the name of the parameter in a template segment, if
+// any, and whether it matches the rest of the path
+func templateParam(seg string) (string, bool) {
+	switch {
+	case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+		// chi and gorilla/mux patterns may restrict the value, e.g. {id:[0-9]+}
+		name := strings.SplitN(strings.Trim(seg, "{}"), ":", 2)[0]
+		return name, false
+	case strings.HasPrefix(seg, ":") && len(seg) > 1:
+		// fiber params may be optional or constrained, e.g. :id? or :id<int>
+		return strings.TrimSuffix(strings.SplitN(seg[1:], "<", 2)[0], "?"), false
+	case seg == "*":
+		return wildcardParam, true
+	case strings.HasPrefix(seg, "*"):
+		return seg[1:], true
+	default:
+		return "", false
+	}
+}
+
+func pathSegments(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, "/")
+}
+
+func unescapePath(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
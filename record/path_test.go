@@ -0,0 +1,66 @@
+package autodoc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestMatchRequestPath(t *testing.T) {
+	tests := []struct {
+		template  string
+		url       string
+		basePaths []string
+		want      []pathParam
+		ok        bool
+	}{
+		{"/users/{id}", "/users/1", nil, []pathParam{{"id", "1"}}, true},
+		{"/users/:id", "/users/1?page=2", nil, []pathParam{{"id", "1"}}, true},
+		{"/users/:id", "http://localhost:8080/users/1", nil, []pathParam{{"id", "1"}}, true},
+		{"/users/:id", "/users/1/", nil, []pathParam{{"id", "1"}}, true},
+		{"/users/", "/users", nil, []pathParam{}, true},
+		{"/", "/", nil, []pathParam{}, true},
+		{"/users/:id", "/api/v1/users/1", []string{"/api/v1/"}, []pathParam{{"id", "1"}}, true},
+		{"/users/:id", "/api/v2/users/1", []string{"/api/v1"}, nil, false},
+		{"/files/*path", "/files/a/b%20c.txt", nil, []pathParam{{"path", "a/b c.txt"}}, true},
+		{"/files/*path", "/files", nil, []pathParam{{"path", ""}}, true},
+		{"/users/{id}", "/users", nil, nil, false},
+		{"/users/{id}", "/users/1/posts", nil, nil, false},
+		{"/users/{id}", "/posts/1", nil, nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := matchRequestPath(tt.template, tt.url, tt.basePaths)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchRequestPath(%q, %q, %v) = %v, %v, want %v, %v", tt.template, tt.url, tt.basePaths, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestOpenAPIPathWarnings(t *testing.T) {
+	re := Recorder{Path: "/users/:id", Method: "get"}
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	re.Record(h)(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+	re.Record(h)(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	o := re.OpenAPI(OpenAPIConfig{BasePaths: []string{"/api"}})
+	op, ok := o.Paths["/users/{id}"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("paths = %v, want /users/{id}", o.Paths)
+	}
+
+	params := op["get"].(map[string]interface{})["parameters"].([]map[string]interface{})
+	if len(params) != 1 || params[0]["name"] != "id" || params[0]["required"] != true {
+		t.Errorf("parameters = %v, want a required id", params)
+	}
+
+	want := []Warning{{
+		Method:  "get",
+		Path:    "/users/:id",
+		URL:     "/users",
+		Message: "request path does not match recorder path. skipping path parameters",
+	}}
+	if !reflect.DeepEqual(o.Warnings, want) {
+		t.Errorf("warnings = %v, want %v", o.Warnings, want)
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
@@ -17,7 +19,11 @@ import (
 )
 
 type Recorder struct {
-	Path           string `json:"path"`
+	Path string `json:"path"`
+	// Route is the router's template for Path when it differs, e.g.
+	// /files/*path for /files/{path}. recorded requests are matched against
+	// it, as the {path} form can't tell a wildcard apart
+	Route          string `json:"route,omitempty"`
 	Method         string `json:"method"`
 	Tag            string `json:"tag"`
 	APIDescription string `json:"api_description"`
@@ -27,16 +33,37 @@ type Recorder struct {
 
 	Records []Entry `json:"records"`
 
-	recordsLock *sync.RWMutex
+	recordsLock sync.RWMutex
 }
 
 type RecorderOptions struct {
 	LogStartedDateTime bool `json:"log_started_date_time"`
+
+	// Redact lists the sensitive values to hide in the records, in addition
+	// to DefaultRedactOptions
+	Redact *RedactOptions `json:"redact,omitempty"`
 }
 
 type Entry struct {
 	har.Entry
 	Options *RecordOptions `json:"options"`
+
+	// RequestSchema and ResponseSchema are reflected from
+	// RecordOptions.RequestType and RecordOptions.ResponseType
+	RequestSchema  map[string]interface{} `json:"request_schema,omitempty"`
+	ResponseSchema map[string]interface{} `json:"response_schema,omitempty"`
+
+	// Files are the file parts of a multipart request body
+	Files []FormFile `json:"files,omitempty"`
+}
+
+// FormFile describes a file part of a multipart request. the file content is
+// not recorded
+type FormFile struct {
+	Name        string `json:"name"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
 }
 
 type RecordOptions struct {
@@ -44,56 +71,169 @@ type RecordOptions struct {
 	RequestSummary      string
 	ResponseDescription string
 
+	// RequestSchemaName and ResponseSchemaName name the body schemas when
+	// they are moved into components/schemas
+	RequestSchemaName  string
+	ResponseSchemaName string
+
+	// RequestType and ResponseType are values of the Go types the handler
+	// binds and responds with, e.g. ExampleRequest{}. when set, the schema
+	// is built from the type instead of being inferred from the example
+	RequestType  interface{} `json:"-"`
+	ResponseType interface{} `json:"-"`
+
+	// T is the running test, e.g. *testing.T. its name is used as the
+	// RequestName when none is given
+	T interface{ Name() string } `json:"-"`
+
 	UseAsRequestExample          bool
 	ExcludeFromOpenAPI           bool
 	ExcludeFromPostmanCollection bool
 }
 
 // ResponseExample returns the response content for the entry
-func (e *Entry) ResponseExample(desc string) map[string]interface{} {
+func (e *Entry) ResponseExample(desc string, cfg ...OpenAPIConfig) map[string]interface{} {
+	config := OpenAPIConfig{}
+	if len(cfg) > 0 {
+		config = cfg[0]
+	}
+
+	return e.responseExample(desc, exampleName(1, e.Options), config)
+}
+
+// responseExample returns the response content with the recorded body attached
+// as an example called name
+func (e *Entry) responseExample(desc, name string, config OpenAPIConfig) map[string]interface{} {
 	switch e.Response.Status {
 	case 301, 302, 303, 307, 308:
 		return map[string]interface{}{
 			"description": desc,
+			"headers":     e.responseHeaders(config, "Location"),
 		}
 	default:
-		return map[string]interface{}{
+		ct := getContentType(e.Response.Headers, e.Response.Content.Text)
+		schema, example := getBodySchema(ct, e.Response.Content.Text)
+		content := map[string]interface{}{
+			"schema": schema,
+		}
+		if e.ResponseSchema != nil {
+			content["schema"] = e.ResponseSchema
+		}
+		if len(e.Response.Content.Text) > 0 && example != nil {
+			content["examples"] = map[string]interface{}{
+				name: Example{
+					Summary: e.Options.RequestSummary,
+					Value:   example,
+				},
+			}
+		}
+
+		res := map[string]interface{}{
 			"description": desc,
 			"content": map[string]interface{}{
-				getContentType(e.Response.Headers): map[string]interface{}{
-					"schema": map[string]interface{}{
-						"type":       "object",
-						"properties": getJSONSchema(e.Response.Content.Text),
-					},
-				},
+				ct: content,
 			},
 		}
+		if headers := e.responseHeaders(config); len(headers) > 0 {
+			res["headers"] = headers
+		}
+		return res
+	}
+
+}
+
+// responseHeaders documents the recorded response headers that are not in the
+// denylist. headers in always are documented regardless
+func (e *Entry) responseHeaders(config OpenAPIConfig, always ...string) map[string]interface{} {
+	denylist := config.ResponseHeaderDenylist
+	if len(denylist) == 0 {
+		denylist = defaultResponseHeaderDenylist
+	}
+
+	denied := map[string]bool{}
+	for _, h := range denylist {
+		denied[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, h := range always {
+		denied[http.CanonicalHeaderKey(h)] = false
+	}
+
+	headers := map[string]interface{}{}
+	for _, h := range e.Response.Headers {
+		name := http.CanonicalHeaderKey(h.Name)
+		if denied[name] {
+			continue
+		}
+		if _, ok := headers[name]; ok {
+			continue
+		}
+
+		schema := getParamSchema([]string{h.Value})
+		headers[name] = map[string]interface{}{
+			"schema":  schema,
+			"example": paramValue(h.Value, schema),
+		}
 	}
 
+	for _, h := range always {
+		name := http.CanonicalHeaderKey(h)
+		if _, ok := headers[name]; !ok {
+			headers[name] = map[string]interface{}{
+				"schema": map[string]interface{}{
+					"type": "string",
+				},
+			}
+		}
+	}
+	return headers
 }
 
 func (re *Recorder) Record(h http.HandlerFunc, opts ...RecordOptions) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// call actual handler
-		ww := createResponseRecorder(w)
-		req := r.Clone(context.Background())
-		if req.Body != nil {
-			body, _ := ioutil.ReadAll(req.Body)
-			req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
-			r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
-		}
-		h(ww, r)
+		serve(w, r, h, func(*http.Request) *Recorder { return re }, opts...)
+	}
+}
+
+// serve calls h and records the request with the recorder returned by pick.
+// pick is called after h, so that it can use the routing done by h. nothing
+// is recorded if it returns nil
+func serve(w http.ResponseWriter, r *http.Request, h http.Handler, pick func(r *http.Request) *Recorder, opts ...RecordOptions) {
+	// call actual handler
+	ww := createResponseRecorder(w)
+	req := r.Clone(context.Background())
+	if req.Body != nil {
+		body, _ := ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+		r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	}
+	h.ServeHTTP(ww, r)
+
+	if re := pick(r); re != nil {
 		re.record(req, ww.recorder.Result(), opts...)
 	}
 }
 
-func (re *Recorder) record(req *http.Request, res *http.Response, opts ...RecordOptions) {
-	if re.recordsLock == nil {
-		re.recordsLock = &sync.RWMutex{}
+// setRoute sets Method, and Path and Route from a router's route template,
+// where they are empty. it's meant for adapters that only learn the route
+// once a request is routed, and is safe to call from concurrent requests
+func (re *Recorder) setRoute(method, route string) {
+	re.recordsLock.Lock()
+	defer re.recordsLock.Unlock()
+
+	if re.Method == "" {
+		re.Method = strings.ToLower(method)
 	}
+	if re.Path == "" {
+		re.Path, re.Route = routePaths(route)
+	}
+}
 
-	if re.Options == nil {
-		re.Options = &RecorderOptions{}
+func (re *Recorder) record(req *http.Request, res *http.Response, opts ...RecordOptions) {
+	// the recorder may be shared by concurrent requests, so it's only
+	// written under the lock
+	options := re.Options
+	if options == nil {
+		options = &RecorderOptions{}
 	}
 
 	rec := Entry{}
@@ -104,6 +244,23 @@ func (re *Recorder) record(req *http.Request, res *http.Response, opts ...Record
 		rec.Options = &RecordOptions{}
 	}
 
+	if rec.Options.RequestName == "" && rec.Options.T != nil {
+		rec.Options.RequestName = rec.Options.T.Name()
+	}
+
+	if rec.Options.RequestType != nil {
+		rec.RequestSchema = getReflectSchema(reflect.TypeOf(rec.Options.RequestType))
+	}
+	if rec.Options.ResponseType != nil {
+		rec.ResponseSchema = getReflectSchema(reflect.TypeOf(rec.Options.ResponseType))
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	}
+
 	l := har.NewLogger()
 	l.SetOption(har.BodyLogging(true))
 	l.RecordRequest("", req)
@@ -112,14 +269,24 @@ func (re *Recorder) record(req *http.Request, res *http.Response, opts ...Record
 
 	// har library doesn't read body if content length etc not set
 	if rec.Entry.Request.PostData == nil && req.Body != nil {
-		body, _ := ioutil.ReadAll(req.Body)
 		rec.Entry.Request.PostData = &har.PostData{
 			Text: string(body),
 		}
 	}
 
+	// keep the form fields and file metadata of multipart bodies, but not the
+	// file contents
+	if mt, ps, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && mt == "multipart/form-data" {
+		params, files := parseMultipart(body, ps["boundary"])
+		rec.Entry.Request.PostData = &har.PostData{
+			MimeType: mt,
+			Params:   params,
+		}
+		rec.Files = files
+	}
+
 	// to prevent constant changes
-	if !re.Options.LogStartedDateTime {
+	if !options.LogStartedDateTime {
 		rec.Entry.StartedDateTime = time.Time{}
 		rec.Entry.Time = 0
 	}
@@ -150,6 +317,12 @@ func (re *Recorder) record(req *http.Request, res *http.Response, opts ...Record
 		})
 	}
 
+	redact := DefaultRedactOptions
+	if options.Redact != nil {
+		redact = options.Redact.withDefaults()
+	}
+	redact.redact(&rec)
+
 	re.recordsLock.Lock()
 	re.Records = append(re.Records, rec)
 	re.recordsLock.Unlock()
@@ -193,6 +366,9 @@ func (r *responseRecorder) CloseNotify() <-chan bool {
 }
 
 func (re *Recorder) JSON() []byte {
+	re.recordsLock.RLock()
+	defer re.recordsLock.RUnlock()
+
 	j, _ := json.Marshal(re)
 	return j
 }
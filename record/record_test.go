@@ -0,0 +1,366 @@
+package autodoc
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestResponseExampleHeaders(t *testing.T) {
+	re := Recorder{Path: "/foo", Method: "get"}
+	h := re.Record(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("X-Rate-Limit-Remaining", "10")
+		w.Write([]byte(`{}`))
+	})
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/foo", nil))
+
+	want := map[string]interface{}{
+		"X-Rate-Limit-Remaining": map[string]interface{}{
+			"schema":  map[string]interface{}{"type": "integer"},
+			"example": int64(10),
+		},
+	}
+	got := re.Records[0].ResponseExample("")["headers"]
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("headers = %#v, want %#v", got, want)
+	}
+
+	got = re.Records[0].ResponseExample("", OpenAPIConfig{ResponseHeaderDenylist: []string{"x-rate-limit-remaining", "content-type"}})["headers"]
+	if _, ok := got.(map[string]interface{})["Date"]; !ok {
+		t.Errorf("headers = %#v, want Date to be documented", got)
+	}
+}
+
+func TestOpenAPIParameters(t *testing.T) {
+	re := Recorder{Path: "/users/{id}", Method: "get"}
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	re.Record(h, RecordOptions{RequestName: "first"})(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1?page=1&q=foo", nil))
+	re.Record(h, RecordOptions{RequestName: "second"})(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/2?page=2", nil))
+
+	op := re.OpenAPI().Paths["/users/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+	if _, ok := op["requestBody"]; ok {
+		t.Errorf("requestBody = %v, want none", op["requestBody"])
+	}
+
+	required := map[string]bool{}
+	for _, p := range op["parameters"].([]map[string]interface{}) {
+		if p["in"] == "header" {
+			continue
+		}
+		required[p["name"].(string)] = p["required"] == true
+		if p["name"] == "id" {
+			want := map[string]interface{}{
+				"1. first":  map[string]interface{}{"value": int64(1)},
+				"2. second": map[string]interface{}{"value": int64(2)},
+			}
+			if !reflect.DeepEqual(p["examples"], want) {
+				t.Errorf("id examples = %v, want %v", p["examples"], want)
+			}
+		}
+	}
+
+	want := map[string]bool{"id": true, "page": true, "q": false}
+	if !reflect.DeepEqual(required, want) {
+		t.Errorf("required = %v, want %v", required, want)
+	}
+}
+
+func TestOpenAPIExampleNames(t *testing.T) {
+	re := Recorder{Path: "/users", Method: "post"}
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1}`))
+	}
+	post := func(opts RecordOptions) {
+		req := httptest.NewRequest(http.MethodPost, "/users?notify=true", bytes.NewBufferString(`{"name":"foo"}`))
+		req.Header.Set("Content-Type", "application/json")
+		re.Record(h, opts)(httptest.NewRecorder(), req)
+	}
+	post(RecordOptions{RequestName: "plain"})
+	post(RecordOptions{RequestName: "excluded", ExcludeFromOpenAPI: true})
+	post(RecordOptions{RequestName: "example", UseAsRequestExample: true})
+
+	op := re.OpenAPI().Paths["/users"].(map[string]interface{})["post"].(map[string]interface{})
+	keys := func(m interface{}) []string {
+		res := []string{}
+		for _, k := range reflect.ValueOf(m).MapKeys() {
+			res = append(res, k.String())
+		}
+		sort.Strings(res)
+		return res
+	}
+
+	body := op["requestBody"].(RequestBody).Content["application/json"].Examples
+	if got, want := keys(body), []string{"2. example"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("request body examples = %v, want %v", got, want)
+	}
+	for _, p := range op["parameters"].([]map[string]interface{}) {
+		if p["name"] != "notify" {
+			continue
+		}
+		if got, want := keys(p["examples"]), []string{"1. plain", "2. example"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("parameter examples = %v, want %v", got, want)
+		}
+	}
+	res := op["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+	if got, want := keys(res["examples"]), []string{"1. plain", "2. example"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("response examples = %v, want %v", got, want)
+	}
+}
+
+func TestOpenAPIParameterSchemas(t *testing.T) {
+	re := Recorder{Path: "/orders/{id}", Method: "get"}
+	h := func(w http.ResponseWriter, r *http.Request) {}
+	for _, url := range []string{
+		"/orders/6f1c2a4e-8b0d-4a3e-9c5f-1d2e3f4a5b6c?status=open&tag=1&since=2021-01-02&zip=01234",
+		"/orders/0b9e8d7c-6a5f-4e3d-2c1b-0a9f8e7d6c5b?status=closed&tag=2&tag=3&since=2021-02-03&zip=98765",
+		"/orders/1a2b3c4d-5e6f-4a1b-8c9d-0e1f2a3b4c5d?status=open&price=1.5&zip=12345",
+	} {
+		re.Record(h)(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+	}
+
+	op := re.OpenAPI().Paths["/orders/{id}"].(map[string]interface{})["get"].(map[string]interface{})
+	got := map[string]interface{}{}
+	for _, p := range op["parameters"].([]map[string]interface{}) {
+		if p["in"] != "header" {
+			got[p["name"].(string)] = p["schema"]
+		}
+	}
+	want := map[string]interface{}{
+		"id":     map[string]interface{}{"type": "string", "format": "uuid"},
+		"status": map[string]interface{}{"type": "string", "enum": []interface{}{"closed", "open"}},
+		"tag": map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"type": "integer"},
+		},
+		"since": map[string]interface{}{"type": "string", "format": "date"},
+		"price": map[string]interface{}{"type": "number"},
+		"zip":   map[string]interface{}{"type": "string"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("schemas = %v, want %v", got, want)
+	}
+
+	for _, p := range op["parameters"].([]map[string]interface{}) {
+		if p["name"] != "tag" {
+			continue
+		}
+		if p["style"] != "form" || p["explode"] != true {
+			t.Errorf("tag style = %v, explode = %v, want form, true", p["style"], p["explode"])
+		}
+		example := p["examples"].(map[string]interface{})["2. Example"].(map[string]interface{})["value"]
+		if want := []interface{}{int64(2), int64(3)}; !reflect.DeepEqual(example, want) {
+			t.Errorf("tag example = %v, want %v", example, want)
+		}
+	}
+}
+
+func TestOpenAPIResponseExamples(t *testing.T) {
+	re := Recorder{Path: "/users", Method: "get"}
+	h := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}
+	}
+	re.Record(h(`[]`), RecordOptions{RequestName: "empty"})(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	re.Record(h(`[{"id":1}]`), RecordOptions{T: t})(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	op := re.OpenAPI().Paths["/users"].(map[string]interface{})["get"].(map[string]interface{})
+	content := op["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})
+	for _, c := range content {
+		examples := c.(map[string]interface{})["examples"].(map[string]interface{})
+		for _, name := range []string{"1. empty", "2. " + t.Name()} {
+			if _, ok := examples[name]; !ok {
+				t.Errorf("examples = %v, want %q", examples, name)
+			}
+		}
+
+		items := c.(map[string]interface{})["schema"].(map[string]interface{})["items"]
+		if items == nil {
+			t.Errorf("schema items missing, want them merged from the second example")
+		}
+	}
+}
+
+func TestResponseExampleMediaTypes(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		schema      map[string]interface{}
+		example     interface{}
+	}{
+		{
+			contentType: "application/pdf",
+			body:        "%PDF-1.4",
+			schema:      map[string]interface{}{"type": "string", "format": "binary"},
+		},
+		{
+			contentType: "text/csv",
+			body:        "id,name\n1,foo\n",
+			schema:      map[string]interface{}{"type": "string"},
+			example:     "id,name\n1,foo\n",
+		},
+		{
+			contentType: "application/xml",
+			body:        `<user><id>1</id></user>`,
+			schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"id": map[string]interface{}{"type": "integer", "example": int64(1)},
+				},
+				"required": []string{"id"},
+				"xml":      map[string]interface{}{"name": "user"},
+			},
+			example: `<user><id>1</id></user>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			re := Recorder{Path: "/export", Method: "get"}
+			re.Record(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(tt.body))
+			})(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/export", nil))
+
+			content := re.Records[0].ResponseExample("")["content"].(map[string]interface{})[tt.contentType].(map[string]interface{})
+			if !reflect.DeepEqual(content["schema"], tt.schema) {
+				t.Errorf("schema = %#v, want %#v", content["schema"], tt.schema)
+			}
+
+			var example interface{}
+			if examples, ok := content["examples"].(map[string]interface{}); ok {
+				example = examples["1. Example"].(Example).Value
+			}
+			if !reflect.DeepEqual(example, tt.example) {
+				t.Errorf("example = %#v, want %#v", example, tt.example)
+			}
+		})
+	}
+}
+
+func TestRecordRedaction(t *testing.T) {
+	re := Recorder{Path: "/login", Method: "post"}
+	h := re.Record(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"user":{"id":1,"token":"abc"},"expires_in":3600}`))
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/login?access_token=xyz&page=1", bytes.NewBufferString(`{"email":"a@b.c","password":"hunter2","pin":1234}`))
+	r.Header.Set("Authorization", "Bearer xyz")
+	r.Header.Set("Content-Type", "application/json")
+	h(httptest.NewRecorder(), r)
+
+	rec := re.Records[0]
+	if got := rec.Request.URL; got != "/login?access_token=REDACTED&page=1" {
+		t.Errorf("url = %q", got)
+	}
+	for _, h := range rec.Request.Headers {
+		if h.Name == "Authorization" && h.Value != "Bearer REDACTED" {
+			t.Errorf("authorization = %q", h.Value)
+		}
+	}
+
+	// custom rules are added to the defaults
+	re.Options = &RecorderOptions{Redact: &RedactOptions{JSONPaths: []string{"pin"}, Patterns: []string{`a@b\.c`}}}
+	r = httptest.NewRequest(http.MethodPost, "/login?access_token=xyz", bytes.NewBufferString(`{"email":"a@b.c","password":"hunter2","pin":1234}`))
+	r.Header.Set("Authorization", "Bearer xyz")
+	h(httptest.NewRecorder(), r)
+
+	want := `{"email":"REDACTED","password":"REDACTED","pin":0}`
+	if got := re.Records[1].Request.PostData.Text; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+	if got := re.Records[1].Request.URL; got != "/login?access_token=REDACTED" {
+		t.Errorf("url = %q", got)
+	}
+	if got := authorization(re.Records[1]); got != "Bearer REDACTED" {
+		t.Errorf("authorization = %q", got)
+	}
+
+	re.Options.Redact.DisableDefaults = true
+	h(httptest.NewRecorder(), r)
+	if got := authorization(re.Records[2]); got != "Bearer xyz" {
+		t.Errorf("authorization with defaults disabled = %q", got)
+	}
+
+	schema := getJSONSchema([]byte(re.Records[1].Request.PostData.Text))
+	if typ := schema["properties"].(map[string]interface{})["pin"].(map[string]interface{})["type"]; typ != "integer" {
+		t.Errorf("pin type = %v, want integer", typ)
+	}
+
+	want = `{"user":{"id":1,"token":"REDACTED"},"expires_in":3600}`
+	if got := string(re.Records[0].Response.Content.Text); got != want {
+		t.Errorf("response = %s, want %s", got, want)
+	}
+}
+
+func authorization(e Entry) string {
+	for _, h := range e.Request.Headers {
+		if h.Name == "Authorization" {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+func TestRedactJSON(t *testing.T) {
+	paths := [][]string{{"password"}, {"user", "id"}, {"key"}, {"session"}}
+	body := `{"z":1.50, "html":"<a>", "user": {"id": "0b6e7a3c-6f5c-4d0e-9a8b-1c2d3e4f5a6b", "key": {"a": [1, 2]}},
+		"session": "2022-01-02T15:04:05Z", "password": "a\"b"}`
+
+	want := `{"z":1.50, "html":"<a>", "user": {"id": "00000000-0000-0000-0000-000000000000", "key": "REDACTED"},
+		"session": "1970-01-01T00:00:00Z", "password": "REDACTED"}`
+	if got := string(redactJSON([]byte(body), paths, "REDACTED")); got != want {
+		t.Errorf("redactJSON() = %s, want %s", got, want)
+	}
+}
+
+func TestRedactQueryOrder(t *testing.T) {
+	re := Recorder{Path: "/login", Method: "get"}
+	re.Record(func(w http.ResponseWriter, r *http.Request) {})(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/login?z=1&token=abc&a=b%20c", nil))
+
+	if got, want := re.Records[0].Request.URL, "/login?z=1&token=REDACTED&a=b%20c"; got != want {
+		t.Errorf("url = %q, want %q", got, want)
+	}
+}
+
+func TestOpenAPIHeaderParameters(t *testing.T) {
+	re := Recorder{Path: "/me", Method: "get"}
+	r := httptest.NewRequest(http.MethodGet, "/me", nil)
+	r.Header.Set("Authorization", "Bearer xyz")
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("User-Agent", "test")
+	r.Header.Set("X-Request-Id", "1")
+	r.Header.Set("X-Tenant", "foo")
+	re.Record(func(w http.ResponseWriter, r *http.Request) {})(httptest.NewRecorder(), r)
+
+	names := func(o OpenAPI) []string {
+		op := o.Paths["/me"].(map[string]interface{})["get"].(map[string]interface{})
+		names := []string{}
+		for _, p := range op["parameters"].([]map[string]interface{}) {
+			names = append(names, p["name"].(string))
+		}
+		return names
+	}
+
+	o := re.OpenAPI()
+	if got, want := names(o), []string{"X-Request-Id", "X-Tenant"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parameters = %v, want %v", got, want)
+	}
+
+	want := map[string]interface{}{"type": "http", "scheme": "bearer"}
+	if got := o.Components["securitySchemes"].(map[string]interface{})["bearerAuth"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("bearerAuth = %v, want %v", got, want)
+	}
+
+	o = re.OpenAPI(OpenAPIConfig{HeaderAllowlist: []string{"x-tenant", "authorization"}})
+	if got, want := names(o), []string{"X-Tenant"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parameters = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,382 @@
+package autodoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/martian/har"
+)
+
+// RedactOptions lists the values that are replaced with a placeholder before
+// a record is stored
+type RedactOptions struct {
+	// Headers are request and response header names, matched case
+	// insensitively. cookies are redacted along with the Cookie and
+	// Set-Cookie headers
+	Headers []string `json:"headers"`
+	// QueryParams are query parameter names
+	QueryParams []string `json:"query_params"`
+	// JSONPaths are dot separated paths into JSON bodies, e.g. user.password.
+	// `*` matches any key or array index, and a path without dots matches the
+	// key at any depth. form fields are matched by name
+	JSONPaths []string `json:"json_paths"`
+	// Patterns are regular expressions replaced in the request and response
+	// body text
+	Patterns []string `json:"patterns"`
+	// Placeholder replaces redacted strings. defaults to REDACTED
+	Placeholder string `json:"placeholder"`
+	// DisableDefaults stops DefaultRedactOptions from being added to these
+	// rules
+	DisableDefaults bool `json:"disable_defaults"`
+}
+
+// DefaultRedactOptions are added to the rules of RecorderOptions.Redact, and
+// used alone when it's nil
+var DefaultRedactOptions = RedactOptions{
+	Headers: []string{
+		"Authorization",
+		"Cookie",
+		"Proxy-Authorization",
+		"Set-Cookie",
+		"X-Api-Key",
+		"X-Auth-Token",
+	},
+	QueryParams: []string{
+		"access_token",
+		"api_key",
+		"apikey",
+		"token",
+	},
+	JSONPaths: []string{
+		"access_token",
+		"api_key",
+		"client_secret",
+		"password",
+		"refresh_token",
+		"secret",
+		"token",
+	},
+}
+
+// withDefaults returns the rules of o with DefaultRedactOptions added, unless
+// DisableDefaults is set
+func (o RedactOptions) withDefaults() RedactOptions {
+	if o.DisableDefaults {
+		return o
+	}
+
+	d := DefaultRedactOptions
+	o.Headers = append(append([]string{}, d.Headers...), o.Headers...)
+	o.QueryParams = append(append([]string{}, d.QueryParams...), o.QueryParams...)
+	o.JSONPaths = append(append([]string{}, d.JSONPaths...), o.JSONPaths...)
+	o.Patterns = append(append([]string{}, d.Patterns...), o.Patterns...)
+	if o.Placeholder == "" {
+		o.Placeholder = d.Placeholder
+	}
+	return o
+}
+
+// redact replaces the sensitive values of the entry in place
+func (o *RedactOptions) redact(e *Entry) {
+	placeholder := o.Placeholder
+	if placeholder == "" {
+		placeholder = "REDACTED"
+	}
+
+	headers := map[string]bool{}
+	for _, h := range o.Headers {
+		headers[http.CanonicalHeaderKey(h)] = true
+	}
+	query := map[string]bool{}
+	for _, q := range o.QueryParams {
+		query[q] = true
+	}
+	patterns := []*regexp.Regexp{}
+	for _, p := range o.Patterns {
+		if rgx, err := regexp.Compile(p); err == nil {
+			patterns = append(patterns, rgx)
+		}
+	}
+	paths := [][]string{}
+	for _, p := range o.JSONPaths {
+		paths = append(paths, strings.Split(p, "."))
+	}
+
+	req := e.Request
+	redactHeaders(req.Headers, headers, placeholder)
+	if headers["Cookie"] {
+		redactCookies(req.Cookies, placeholder)
+	}
+
+	redacted := false
+	for i, q := range req.QueryString {
+		if query[q.Name] {
+			req.QueryString[i].Value = redactValue(q.Value, placeholder)
+			redacted = true
+		}
+	}
+	if u, err := url.Parse(req.URL); err == nil && redacted {
+		u.RawQuery = redactRawQuery(u.RawQuery, query, placeholder)
+		req.URL = u.String()
+	}
+
+	if req.PostData != nil {
+		for i, p := range req.PostData.Params {
+			if matchPath(paths, []string{p.Name}, true) {
+				req.PostData.Params[i].Value = redactValue(p.Value, placeholder)
+			}
+		}
+		text := redactJSON([]byte(req.PostData.Text), paths, placeholder)
+		req.PostData.Text = string(redactPatterns(text, patterns, placeholder))
+	}
+
+	res := e.Response
+	redactHeaders(res.Headers, headers, placeholder)
+	if headers["Set-Cookie"] {
+		redactCookies(res.Cookies, placeholder)
+	}
+	if res.Content != nil {
+		text := redactJSON(res.Content.Text, paths, placeholder)
+		res.Content.Text = redactPatterns(text, patterns, placeholder)
+	}
+}
+
+func redactHeaders(hs []har.Header, names map[string]bool, placeholder string) {
+	for i, h := range hs {
+		name := http.CanonicalHeaderKey(h.Name)
+		if !names[name] {
+			continue
+		}
+
+		hs[i].Value = placeholder
+		// keep the scheme so that the kind of auth can still be documented
+		if name == "Authorization" || name == "Proxy-Authorization" {
+			if j := strings.Index(h.Value, " "); j > 0 {
+				hs[i].Value = h.Value[:j] + " " + placeholder
+			}
+		}
+	}
+}
+
+func redactCookies(cs []har.Cookie, placeholder string) {
+	for i := range cs {
+		cs[i].Value = placeholder
+	}
+}
+
+// formatPlaceholders replace strings that have a format, so that the
+// documented format doesn't change
+var formatPlaceholders = map[string]string{
+	"date-time": "1970-01-01T00:00:00Z",
+	"date":      "1970-01-01",
+	"uuid":      "00000000-0000-0000-0000-000000000000",
+	"email":     "redacted@example.com",
+	"ipv4":      "0.0.0.0",
+	"ipv6":      "::",
+	"uri":       "https://example.com",
+}
+
+// redactValue replaces a value with one of the same predicted type, so that
+// the documented type doesn't change
+func redactValue(v, placeholder string) string {
+	switch predictValueType(v) {
+	case "number":
+		return "0"
+	case "boolean":
+		return "false"
+	default:
+		return redactString(v, placeholder)
+	}
+}
+
+// redactString replaces a string with one of the same format, or with
+// placeholder if it has none
+func redactString(v, placeholder string) string {
+	if p, ok := formatPlaceholders[getStringFormat(v)]; ok {
+		return p
+	}
+	return placeholder
+}
+
+// redactRawQuery replaces the values of the named query parameters, keeping
+// the order and encoding of the rest of the query
+func redactRawQuery(raw string, names map[string]bool, placeholder string) string {
+	parts := strings.Split(raw, "&")
+	for i, part := range parts {
+		kv := strings.SplitN(part, "=", 2)
+		name, err := url.QueryUnescape(kv[0])
+		if err != nil || !names[name] {
+			continue
+		}
+		value := ""
+		if len(kv) == 2 {
+			value, _ = url.QueryUnescape(kv[1])
+		}
+		parts[i] = kv[0] + "=" + url.QueryEscape(redactValue(value, placeholder))
+	}
+	return strings.Join(parts, "&")
+}
+
+// jsonSpan is a value of a JSON body to replace
+type jsonSpan struct {
+	start, end int
+	value      []byte
+}
+
+// redactJSON replaces the values at paths in a JSON body. only the redacted
+// values are rewritten, so the key order, number literals and escaping of
+// the rest of the body are kept. the body is left as is if it isn't JSON
+func redactJSON(b []byte, paths [][]string, placeholder string) []byte {
+	if len(paths) == 0 || !json.Valid(b) {
+		return b
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	spans := []jsonSpan{}
+	if err := redactJSONValue(d, b, nil, paths, placeholder, &spans); err != nil || len(spans) == 0 {
+		return b
+	}
+
+	r := []byte{}
+	last := 0
+	for _, s := range spans {
+		r = append(r, b[last:s.start]...)
+		r = append(r, s.value...)
+		last = s.end
+	}
+	return append(r, b[last:]...)
+}
+
+// redactJSONValue reads the next value of b from d, adding the spans of the
+// values to redact
+func redactJSONValue(d *json.Decoder, b []byte, path []string, paths [][]string, placeholder string, spans *[]jsonSpan) error {
+	// the value starts after the whitespace and separators that follow the
+	// previous token
+	start := int(d.InputOffset())
+	for start < len(b) && strings.IndexByte(" \t\r\n,:", b[start]) >= 0 {
+		start++
+	}
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+
+	if len(path) > 0 && tok != nil && matchPath(paths, path, false) {
+		if _, ok := tok.(json.Delim); ok {
+			if err := skipJSON(d); err != nil {
+				return err
+			}
+		}
+		*spans = append(*spans, jsonSpan{
+			start: start,
+			end:   int(d.InputOffset()),
+			value: redactedJSON(tok, placeholder),
+		})
+		return nil
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		for d.More() {
+			key, err := d.Token()
+			if err != nil {
+				return err
+			}
+			k, _ := key.(string)
+			if err := redactJSONValue(d, b, append(path, k), paths, placeholder, spans); err != nil {
+				return err
+			}
+		}
+		_, err = d.Token()
+	case json.Delim('['):
+		for d.More() {
+			if err := redactJSONValue(d, b, append(path, "*"), paths, placeholder, spans); err != nil {
+				return err
+			}
+		}
+		_, err = d.Token()
+	}
+	return err
+}
+
+// skipJSON reads the rest of an object or array whose opening delimiter was
+// just read
+func skipJSON(d *json.Decoder) error {
+	for depth := 1; depth > 0; {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// redactedJSON returns the JSON that replaces a redacted value of the same
+// type. objects and arrays are replaced by the placeholder
+func redactedJSON(tok json.Token, placeholder string) []byte {
+	switch tok := tok.(type) {
+	case json.Number:
+		return []byte("0")
+	case bool:
+		return []byte("false")
+	case string:
+		placeholder = redactString(tok, placeholder)
+	}
+
+	b := &bytes.Buffer{}
+	e := json.NewEncoder(b)
+	e.SetEscapeHTML(false)
+	e.Encode(placeholder)
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
+
+// matchPath reports whether path matches one of paths. a path without dots
+// matches the last key at any depth. flat is set for form fields, which only
+// have a name
+func matchPath(paths [][]string, path []string, flat bool) bool {
+	for _, p := range paths {
+		if len(p) == 1 {
+			if p[0] == path[len(path)-1] {
+				return true
+			}
+			continue
+		}
+		if flat || len(p) != len(path) {
+			continue
+		}
+
+		match := true
+		for i := range p {
+			if p[i] != "*" && p[i] != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func redactPatterns(b []byte, patterns []*regexp.Regexp, placeholder string) []byte {
+	if !utf8.Valid(b) {
+		return b
+	}
+	for _, p := range patterns {
+		b = p.ReplaceAll(b, []byte(placeholder))
+	}
+	return b
+}
@@ -0,0 +1,173 @@
+package autodoc
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Enumer can be implemented by types that only allow a fixed set of values,
+// which are then documented as the schema's enum
+type Enumer interface {
+	Enum() []interface{}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	enumerType     = reflect.TypeOf((*Enumer)(nil)).Elem()
+)
+
+// getReflectSchema builds a schema from a Go type using the same rules as
+// encoding/json
+func getReflectSchema(t reflect.Type) map[string]interface{} {
+	return reflectSchema(t, map[reflect.Type]bool{})
+}
+
+func reflectSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		m := reflectSchema(t.Elem(), seen)
+		m["nullable"] = true
+		return m
+	}
+
+	m := reflectKind(t, seen)
+	if t.Implements(enumerType) {
+		if e, ok := reflect.Zero(t).Interface().(Enumer); ok {
+			m["enum"] = e.Enum()
+		}
+	}
+	return m
+}
+
+func reflectKind(t reflect.Type, seen map[reflect.Type]bool) map[string]interface{} {
+	if t == timeType {
+		return map[string]interface{}{
+			"type":   "string",
+			"format": "date-time",
+		}
+	}
+	// a raw message can hold any JSON value
+	if t == rawMessageType {
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		// encoding/json writes []byte as a base64 string, but byte arrays as
+		// arrays of numbers
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": reflectSchema(t.Elem(), seen),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": reflectSchema(t.Elem(), seen),
+		}
+	case reflect.Struct:
+		// a struct nested in itself is documented as a plain object
+		if seen[t] {
+			return map[string]interface{}{"type": "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		m := map[string]interface{}{
+			"type": "object",
+		}
+		props := map[string]interface{}{}
+		required := []string{}
+		reflectFields(t, props, &required, seen)
+		if len(props) > 0 {
+			m["properties"] = props
+		}
+		if len(required) > 0 {
+			sort.Strings(required)
+			m["required"] = required
+		}
+		return m
+	default:
+		// interfaces and anything else can hold any value
+		return map[string]interface{}{}
+	}
+}
+
+// reflectFields adds the json fields of struct t to props, flattening
+// embedded structs the way encoding/json does
+func reflectFields(t reflect.Type, props map[string]interface{}, required *[]string, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				reflectFields(ft, props, required, seen)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+
+		var schema map[string]interface{}
+		if opts["string"] {
+			schema = map[string]interface{}{"type": "string"}
+		} else {
+			schema = reflectSchema(ft, seen)
+		}
+		props[name] = schema
+
+		rules := f.Tag.Get("binding")
+		if v := f.Tag.Get("validate"); v != "" {
+			rules = strings.Trim(rules+","+v, ",")
+		}
+		req := applyValidation(schema, rules)
+
+		if req || !opts["omitempty"] {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func parseTag(tag string) (string, map[string]bool) {
+	parts := strings.Split(tag, ",")
+	opts := map[string]bool{}
+	for _, o := range parts[1:] {
+		opts[o] = true
+	}
+	return parts[0], opts
+}
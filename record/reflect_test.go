@@ -0,0 +1,149 @@
+package autodoc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testStatus string
+
+func (testStatus) Enum() []interface{} {
+	return []interface{}{"active", "inactive"}
+}
+
+type testBase struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type testUser struct {
+	testBase
+	Name     string         `json:"name"`
+	Email    *string        `json:"email,omitempty"`
+	Status   testStatus     `json:"status"`
+	Tags     []string       `json:"tags,omitempty"`
+	Meta     map[string]int `json:"meta,omitempty"`
+	Count    int            `json:"count,string"`
+	Parent   *testUser      `json:"parent,omitempty"`
+	Ignored  string         `json:"-"`
+	internal string
+}
+
+func TestGetReflectSchema(t *testing.T) {
+	want := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id":         map[string]interface{}{"type": "integer", "format": "int64"},
+			"created_at": map[string]interface{}{"type": "string", "format": "date-time"},
+			"name":       map[string]interface{}{"type": "string"},
+			"email":      map[string]interface{}{"type": "string", "nullable": true},
+			"status":     map[string]interface{}{"type": "string", "enum": []interface{}{"active", "inactive"}},
+			"tags": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+			"meta": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "integer", "format": "int64"},
+			},
+			"count":  map[string]interface{}{"type": "string"},
+			"parent": map[string]interface{}{"type": "object", "nullable": true},
+		},
+		"required": []string{"count", "created_at", "id", "name", "status"},
+	}
+
+	got := getReflectSchema(reflect.TypeOf(testUser{}))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getReflectSchema() = %#v, want %#v", got, want)
+	}
+}
+
+func TestGetReflectSchemaBytes(t *testing.T) {
+	type payload struct {
+		Data []byte          `json:"data"`
+		Hash [4]byte         `json:"hash"`
+		Raw  json.RawMessage `json:"raw"`
+	}
+	want := map[string]interface{}{
+		"data": map[string]interface{}{"type": "string", "format": "byte"},
+		"hash": map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"type": "integer", "format": "int32"},
+		},
+		"raw": map[string]interface{}{},
+	}
+
+	got := getReflectSchema(reflect.TypeOf(payload{}))["properties"]
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("properties = %#v, want %#v", got, want)
+	}
+}
+
+func TestGetReflectSchemaValidation(t *testing.T) {
+	type request struct {
+		Name  string   `json:"name,omitempty" binding:"required,min=1,max=64"`
+		Kind  string   `json:"kind,omitempty" binding:"oneof=a b 'c d'"`
+		Age   int      `json:"age,omitempty" validate:"gte=0,lt=150"`
+		Email string   `json:"email,omitempty" binding:"omitempty,email"`
+		Code  string   `json:"code,omitempty" binding:"alphanum"`
+		IDs   []string `json:"ids,omitempty" binding:"required,max=10,dive,uuid"`
+	}
+
+	want := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "minLength": int64(1), "maxLength": int64(64)},
+			"kind":  map[string]interface{}{"type": "string", "enum": []interface{}{"a", "b", "c d"}},
+			"age":   map[string]interface{}{"type": "integer", "format": "int64", "minimum": int64(0), "maximum": int64(150), "exclusiveMaximum": true},
+			"email": map[string]interface{}{"type": "string", "format": "email"},
+			"code":  map[string]interface{}{"type": "string", "pattern": "^[a-zA-Z0-9]+$"},
+			"ids": map[string]interface{}{
+				"type":     "array",
+				"maxItems": int64(10),
+				"items":    map[string]interface{}{"type": "string", "format": "uuid"},
+			},
+		},
+		"required": []string{"ids", "name"},
+	}
+
+	got := getReflectSchema(reflect.TypeOf(request{}))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getReflectSchema() = %#v, want %#v", got, want)
+	}
+}
+
+func TestOpenAPIResponseType(t *testing.T) {
+	type response struct {
+		ID    int64  `json:"id"`
+		Email string `json:"email,omitempty" binding:"required,email"`
+	}
+	want := getReflectSchema(reflect.TypeOf(response{}))
+
+	for _, typedFirst := range []bool{true, false} {
+		re := Recorder{Path: "/users", Method: "get"}
+		h := func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":1.5,"name":"foo"}`))
+		}
+		opts := []RecordOptions{{ResponseType: response{}}, {}}
+		if !typedFirst {
+			opts[0], opts[1] = opts[1], opts[0]
+		}
+		for _, o := range opts {
+			re.Record(h, o)(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+		}
+
+		op := re.OpenAPI().Paths["/users"].(map[string]interface{})["get"].(map[string]interface{})
+		content := op["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+		if !reflect.DeepEqual(content["schema"], want) {
+			t.Errorf("typed first %v: schema = %#v, want %#v", typedFirst, content["schema"], want)
+		}
+		if examples, _ := content["examples"].(map[string]interface{}); len(examples) != 2 {
+			t.Errorf("typed first %v: examples = %v, want 2", typedFirst, examples)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package autodoc
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry holds a Recorder per method and path template, so that a whole
+// router can be recorded by a middleware
+type Registry struct {
+	// Tag and Options are set on the recorders created by the registry
+	Tag     string
+	Options *RecorderOptions
+
+	recorders map[string]*Recorder
+	lock      sync.Mutex
+}
+
+// Recorder returns the recorder for a method and path template, creating it
+// on first use. gin style :id and *wildcard params are converted to {id}, so
+// /users/:id and /users/{id} share a recorder
+func (reg *Registry) Recorder(method, route string) *Recorder {
+	method = strings.ToLower(method)
+	path, route := routePaths(route)
+
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+
+	if reg.recorders == nil {
+		reg.recorders = map[string]*Recorder{}
+	}
+	key := method + " " + path
+	re, ok := reg.recorders[key]
+	if !ok {
+		re = &Recorder{
+			Path:    path,
+			Route:   route,
+			Method:  method,
+			Tag:     reg.Tag,
+			Options: reg.Options,
+		}
+		reg.recorders[key] = re
+	}
+	return re
+}
+
+// Recorders returns the recorders sorted by path and method
+func (reg *Registry) Recorders() []*Recorder {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+
+	res := make([]*Recorder, 0, len(reg.recorders))
+	for _, re := range reg.recorders {
+		res = append(res, re)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Path != res[j].Path {
+			return res[i].Path < res[j].Path
+		}
+		return res[i].Method < res[j].Method
+	})
+	return res
+}
+
+// Routes returns the method and path of every recorder, e.g. "get /users/{id}",
+// in the order of Recorders
+func (reg *Registry) Routes() []string {
+	routes := []string{}
+	for _, re := range reg.Recorders() {
+		routes = append(routes, re.Method+" "+re.Path)
+	}
+	return routes
+}
+
+// GenerateFiles writes the file of every recorder. it stops at the first
+// error
+func (reg *Registry) GenerateFiles() error {
+	for _, re := range reg.Recorders() {
+		if err := re.GenerateFile(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Middleware records every request handled by the next handler with the
+// registry's recorder for the request's method and the path template
+// returned by route. route is called after the next handler, once the
+// request is routed. requests without a route are not recorded
+func (reg *Registry) Middleware(route func(r *http.Request) string, opts ...RecordOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serve(w, r, next, func(r *http.Request) *Recorder {
+				path := route(r)
+				if path == "" {
+					return nil
+				}
+				return reg.Recorder(r.Method, path)
+			}, opts...)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package autodoc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := &Registry{}
+
+	r := gin.New()
+	r.Use(GinMiddleware(reg))
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.Header("X-Request-Id", "abc")
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+	})
+	r.GET("/files/*path", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if w.Header().Get("X-Request-Id") != "abc" {
+		t.Errorf("response headers = %v, want X-Request-Id", w.Header())
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/files/a/b.txt", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+	want := []string{"get /files/{path}", "get /users/{id}"}
+	if got := reg.Routes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %v, want %v", got, want)
+	}
+
+	res := reg.Recorder("get", "/users/:id").Records[0].Response
+	if res.Status != http.StatusOK || string(res.Content.Text) != `{"id":"1"}` {
+		t.Errorf("response = %d %s", res.Status, res.Content.Text)
+	}
+	found := false
+	for _, h := range res.Headers {
+		found = found || (h.Name == "X-Request-Id" && h.Value == "abc")
+	}
+	if !found {
+		t.Errorf("headers = %v, want X-Request-Id", res.Headers)
+	}
+
+	files := reg.Recorder("get", "/files/*path")
+	if files.Path != "/files/{path}" || files.Route != "/files/*path" {
+		t.Errorf("recorder path = %s, route = %s, want /files/{path}, /files/*path", files.Path, files.Route)
+	}
+	o := files.OpenAPI()
+	params := o.Paths["/files/{path}"].(map[string]interface{})["get"].(map[string]interface{})["parameters"].([]map[string]interface{})
+	if len(params) != 1 || params[0]["example"] != "a/b.txt" {
+		t.Errorf("parameters = %v, want path a/b.txt", params)
+	}
+}
@@ -0,0 +1,169 @@
+package autodoc
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/martian/har"
+)
+
+var (
+	matchAPIKeyHeader  = regexp.MustCompile(`(?i)^(x-)?(api-?key|auth-?token|access-?token)$`)
+	matchAPIKeyQuery   = regexp.MustCompile(`(?i)^(api_?key|access_token)$`)
+	matchSessionCookie = regexp.MustCompile(`(?i)^(sid|jsessionid|phpsessid|connect\.sid)$|sess`)
+)
+
+// authorizationScheme returns the name and definition of the security scheme
+// used by an Authorization header value
+func authorizationScheme(value string) (string, map[string]interface{}) {
+	scheme := strings.ToLower(strings.SplitN(value, " ", 2)[0])
+	switch scheme {
+	case "bearer":
+		return "bearerAuth", map[string]interface{}{
+			"type":   "http",
+			"scheme": "bearer",
+		}
+	case "basic":
+		return "basicAuth", map[string]interface{}{
+			"type":   "http",
+			"scheme": "basic",
+		}
+	default:
+		return "authorizationHeader", map[string]interface{}{
+			"type": "apiKey",
+			"in":   "header",
+			"name": "Authorization",
+		}
+	}
+}
+
+func apiKeyScheme(in, name string) (string, map[string]interface{}) {
+	return camelCase(name) + pascalCase(in), map[string]interface{}{
+		"type": "apiKey",
+		"in":   in,
+		"name": name,
+	}
+}
+
+func isAPIKeyHeader(name string) bool {
+	return matchAPIKeyHeader.MatchString(name)
+}
+
+func isAPIKeyQuery(name string) bool {
+	return matchAPIKeyQuery.MatchString(name)
+}
+
+// credentials returns the security schemes a recorded request authenticated
+// with, keyed by scheme name
+func credentials(req *har.Request) map[string]interface{} {
+	schemes := map[string]interface{}{}
+	for _, h := range req.Headers {
+		switch {
+		case http.CanonicalHeaderKey(h.Name) == "Authorization":
+			name, scheme := authorizationScheme(h.Value)
+			schemes[name] = scheme
+		case isAPIKeyHeader(h.Name):
+			name, scheme := apiKeyScheme("header", h.Name)
+			schemes[name] = scheme
+		}
+	}
+
+	for _, q := range req.QueryString {
+		if isAPIKeyQuery(q.Name) {
+			name, scheme := apiKeyScheme("query", q.Name)
+			schemes[name] = scheme
+		}
+	}
+
+	for _, c := range req.Cookies {
+		if matchSessionCookie.MatchString(c.Name) {
+			name, scheme := apiKeyScheme("cookie", c.Name)
+			schemes[name] = scheme
+		}
+	}
+	return schemes
+}
+
+// security detects the security schemes used by the recorded requests and
+// returns them along with the operation's security requirements. each set
+// of credentials seen becomes an alternative requirement. the requirements
+// are empty if the operation was only called without credentials, which
+// marks it as public, and nil if that can't be told, so the global security
+// applies. a 401 response counts as evidence that credentials are required
+func (re *Recorder) security() (map[string]interface{}, []map[string]interface{}) {
+	schemes := map[string]interface{}{}
+	security := []map[string]interface{}{}
+	seen := map[string]bool{}
+	add := func(creds map[string]interface{}) {
+		names := sortedKeys(creds)
+		key := strings.Join(names, ",")
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		requirement := map[string]interface{}{}
+		for _, name := range names {
+			schemes[name] = creds[name]
+			requirement[name] = []string{}
+		}
+		security = append(security, requirement)
+	}
+
+	public := false
+	unauthorized := false
+	challenged := map[string]interface{}{}
+	for _, rec := range re.Records {
+		if rec.Options.ExcludeFromOpenAPI {
+			continue
+		}
+
+		status := rec.Response.Status
+		if status == http.StatusUnauthorized {
+			unauthorized = true
+			for _, h := range rec.Response.Headers {
+				if http.CanonicalHeaderKey(h.Name) == "Www-Authenticate" {
+					name, scheme := authorizationScheme(h.Value)
+					challenged[name] = scheme
+				}
+			}
+		}
+
+		creds := credentials(rec.Request)
+		if len(creds) == 0 {
+			if status != http.StatusUnauthorized && status != http.StatusForbidden {
+				public = true
+			}
+			continue
+		}
+		add(creds)
+	}
+
+	if len(security) == 0 {
+		for _, name := range sortedKeys(challenged) {
+			add(map[string]interface{}{name: challenged[name]})
+		}
+	}
+
+	switch {
+	case len(security) == 0 && unauthorized:
+		return schemes, nil
+	case len(security) == 0 && public:
+		return schemes, security
+	case len(security) == 0:
+		return schemes, nil
+	case public && !unauthorized:
+		// credentials are optional
+		security = append(security, map[string]interface{}{})
+	}
+	return schemes, security
+}
+
+func camelCase(s string) string {
+	p := pascalCase(s)
+	if p == "" {
+		return p
+	}
+	return strings.ToLower(p[:1]) + p[1:]
+}
@@ -0,0 +1,105 @@
+package autodoc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAPISecurity(t *testing.T) {
+	type request struct {
+		status  int
+		headers map[string]string
+		url     string
+	}
+	tests := []struct {
+		name     string
+		requests []request
+		schemes  []string
+		security []map[string]interface{}
+	}{
+		{
+			name:     "public",
+			requests: []request{{status: 200}},
+			schemes:  []string{},
+			security: []map[string]interface{}{},
+		},
+		{
+			name: "basic auth required",
+			requests: []request{
+				{status: 200, headers: map[string]string{"Authorization": "Basic Zm9vOmJhcg=="}},
+				{status: 401},
+			},
+			schemes:  []string{"basicAuth"},
+			security: []map[string]interface{}{{"basicAuth": []string{}}},
+		},
+		{
+			name: "optional api key",
+			requests: []request{
+				{status: 200, headers: map[string]string{"X-Api-Key": "foo"}},
+				{status: 200},
+			},
+			schemes: []string{"xApiKeyHeader"},
+			security: []map[string]interface{}{
+				{"xApiKeyHeader": []string{}},
+				{},
+			},
+		},
+		{
+			name: "query key and session cookie",
+			requests: []request{
+				{status: 200, url: "/?api_key=foo"},
+				{status: 200, headers: map[string]string{"Cookie": "session_id=foo"}},
+			},
+			schemes: []string{"apiKeyQuery", "sessionIdCookie"},
+			security: []map[string]interface{}{
+				{"apiKeyQuery": []string{}},
+				{"sessionIdCookie": []string{}},
+			},
+		},
+		{
+			name: "challenged by 401",
+			requests: []request{
+				{status: 401, headers: map[string]string{"WWW-Authenticate": `Bearer realm="api"`}},
+			},
+			schemes:  []string{"bearerAuth"},
+			security: []map[string]interface{}{{"bearerAuth": []string{}}},
+		},
+		{
+			name:     "401 without a challenge",
+			requests: []request{{status: 401}},
+			schemes:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re := Recorder{Path: "/", Method: "get"}
+			for _, r := range tt.requests {
+				r := r
+				url := r.url
+				if url == "" {
+					url = "/"
+				}
+				req := httptest.NewRequest(http.MethodGet, url, nil)
+				for k, v := range r.headers {
+					req.Header.Set(k, v)
+				}
+				re.Record(func(w http.ResponseWriter, req *http.Request) {
+					if v, ok := r.headers["WWW-Authenticate"]; ok {
+						w.Header().Set("WWW-Authenticate", v)
+					}
+					w.WriteHeader(r.status)
+				})(httptest.NewRecorder(), req)
+			}
+
+			schemes, security := re.security()
+			if got := sortedKeys(schemes); !reflect.DeepEqual(got, tt.schemes) {
+				t.Errorf("schemes = %v, want %v", got, tt.schemes)
+			}
+			if !reflect.DeepEqual(security, tt.security) {
+				t.Errorf("security = %#v, want %#v", security, tt.security)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package autodoc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// transport records the requests sent through an http.Client with the
+// recorder picked for each request. requests without a recorder are sent
+// without being recorded
+type transport struct {
+	base http.RoundTripper
+	pick func(r *http.Request) *Recorder
+	opts []RecordOptions
+}
+
+func newTransport(base http.RoundTripper, pick func(r *http.Request) *Recorder, opts []RecordOptions) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{
+		base: base,
+		pick: pick,
+		opts: opts,
+	}
+}
+
+// Transport returns a RoundTripper that records the requests sent through
+// base, which defaults to http.DefaultTransport. the recorder's Method and
+// Path must be set, requests that don't match them are sent without being
+// recorded. use Registry.Transport to record every request. the response is
+// recorded once its body is read to the end or closed
+func (re *Recorder) Transport(base http.RoundTripper, opts ...RecordOptions) http.RoundTripper {
+	if re.Method == "" || re.Path == "" {
+		panic("autodoc: Transport needs the recorder's Method and Path")
+	}
+
+	// copied so that concurrent requests only read them
+	method := strings.ToLower(re.Method)
+	template := re.Path
+	if re.Route != "" {
+		template = re.Route
+	}
+	return newTransport(base, func(r *http.Request) *Recorder {
+		if _, ok := matchTemplate(template, r.URL.EscapedPath()); !ok || strings.ToLower(r.Method) != method {
+			return nil
+		}
+		return re
+	}, opts)
+}
+
+// Transport returns a RoundTripper that records every request sent through
+// base, which defaults to http.DefaultTransport, with the registry's recorder
+// for the request's method and the path template returned by route, e.g.
+// /users/{id}. requests without a route are not recorded
+func (reg *Registry) Transport(base http.RoundTripper, route func(r *http.Request) string, opts ...RecordOptions) http.RoundTripper {
+	return newTransport(base, func(r *http.Request) *Recorder {
+		path := route(r)
+		if path == "" {
+			return nil
+		}
+		return reg.Recorder(r.Method, path)
+	}, opts)
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	re := t.pick(r)
+	if re == nil {
+		return t.base.RoundTrip(r)
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// a RoundTripper must not modify the request, so a copy is sent
+	out := r.Clone(r.Context())
+	if r.Body != nil {
+		out.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	res, err := t.base.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	req := r.Clone(context.Background())
+	if r.Body != nil {
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	recorded := *res
+	res.Body = &recordingBody{
+		ReadCloser: res.Body,
+		done: func(b []byte) {
+			recorded.Body = ioutil.NopCloser(bytes.NewReader(b))
+			re.record(req, &recorded, t.opts...)
+		},
+	}
+	return res, nil
+}
+
+// recordingBody keeps a copy of a response body as it's read, and passes it
+// to done at EOF or when the body is closed, whichever comes first
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	done func([]byte)
+	once sync.Once
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish() {
+	b.once.Do(func() {
+		b.done(b.buf.Bytes())
+	})
+}
@@ -0,0 +1,141 @@
+package autodoc
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		received = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer srv.Close()
+
+	re := Recorder{Path: "/users/{id}", Method: "put"}
+	client := &http.Client{Transport: re.Transport(nil, RecordOptions{UseAsRequestExample: true})}
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL+"/users/1?notify=true", bytes.NewBufferString(`{"name":"foo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if len(re.Records) != 0 {
+		t.Errorf("recorded %d requests before the body was read", len(re.Records))
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	if string(body) != `{"id":1}` || received != `{"name":"foo"}` {
+		t.Errorf("client got %s, server got %s", body, received)
+	}
+
+	// other routes are sent without being recorded
+	res, err = client.Get(srv.URL + "/users/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(res.Body)
+	res.Body.Close()
+
+	if len(re.Records) != 1 {
+		t.Fatalf("recorded %d requests, want 1", len(re.Records))
+	}
+	rec := re.Records[0]
+	if rec.Request.PostData.Text != `{"name":"foo"}` {
+		t.Errorf("request body = %s", rec.Request.PostData.Text)
+	}
+	if rec.Response.Status != http.StatusCreated || string(rec.Response.Content.Text) != `{"id":1}` {
+		t.Errorf("response = %d %s", rec.Response.Status, rec.Response.Content.Text)
+	}
+
+	o := re.OpenAPI()
+	if len(o.Warnings) > 0 {
+		t.Errorf("warnings = %v", o.Warnings)
+	}
+	params := o.Paths["/users/{id}"].(map[string]interface{})["put"].(map[string]interface{})["parameters"].([]map[string]interface{})
+	if len(params) != 2 || params[0]["name"] != "id" || params[0]["example"] != int64(1) {
+		t.Errorf("parameters = %v, want id and notify", params)
+	}
+}
+
+func TestTransportConcurrent(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	re := Recorder{Path: "/users/:id", Method: "get"}
+	client := &http.Client{Transport: re.Transport(nil)}
+
+	const n = 20
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := client.Get(srv.URL + "/users/" + strconv.Itoa(i))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			res.Body.Close()
+		}(i)
+	}
+	wg.Wait()
+
+	if len(re.Records) != n {
+		t.Errorf("recorded %d requests, want %d", len(re.Records), n)
+	}
+}
+
+func TestTransportWithoutRoute(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Transport() didn't panic for a recorder without a path")
+		}
+	}()
+	re := Recorder{}
+	re.Transport(nil)
+}
+
+func TestRegistryTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	reg := &Registry{}
+	client := &http.Client{Transport: reg.Transport(nil, func(r *http.Request) string {
+		if strings.HasPrefix(r.URL.Path, "/users/") {
+			return "/users/:id"
+		}
+		return ""
+	})}
+
+	for _, path := range []string{"/users/1", "/users/2", "/health"} {
+		res, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.ReadAll(res.Body)
+		res.Body.Close()
+	}
+
+	recorders := reg.Recorders()
+	if len(recorders) != 1 || recorders[0].Path != "/users/{id}" || len(recorders[0].Records) != 2 {
+		t.Errorf("recorders = %v, want get /users/{id} with 2 records", recorders)
+	}
+}
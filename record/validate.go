@@ -0,0 +1,139 @@
+package autodoc
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var validationFormats = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"uri":      "uri",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"datetime": "date-time",
+}
+
+var validationPatterns = map[string]string{
+	"alpha":       `^[a-zA-Z]+$`,
+	"alphanum":    `^[a-zA-Z0-9]+$`,
+	"numeric":     `^[-+]?[0-9]+(?:\.[0-9]+)?$`,
+	"number":      `^[0-9]+$`,
+	"hexadecimal": `^(0[xX])?[0-9a-fA-F]+$`,
+	"lowercase":   `^[^A-Z]*$`,
+	"uppercase":   `^[^a-z]*$`,
+	"e164":        `^\+[1-9]?[0-9]{7,14}$`,
+}
+
+var matchOneOfValue = regexp.MustCompile(`'[^']*'|\S+`)
+
+// applyValidation adds the constraints of go-playground/validator rules, as
+// used in gin's binding tag, to schema. it reports whether the rules make the
+// field required
+func applyValidation(schema map[string]interface{}, rules string) bool {
+	required := false
+	for i, rule := range strings.Split(rules, ",") {
+		name, param := rule, ""
+		if j := strings.Index(rule, "="); j >= 0 {
+			name, param = rule[:j], rule[j+1:]
+		}
+
+		switch name {
+		case "required":
+			required = true
+		case "dive":
+			// the remaining rules apply to the elements
+			if items, ok := schema["items"].(map[string]interface{}); ok {
+				applyValidation(items, strings.Join(strings.Split(rules, ",")[i+1:], ","))
+			}
+			return required
+		case "min", "gte":
+			setBound(schema, "minLength", "minimum", "minItems", param, false)
+		case "max", "lte":
+			setBound(schema, "maxLength", "maximum", "maxItems", param, false)
+		case "gt":
+			setBound(schema, "minLength", "minimum", "minItems", param, true)
+		case "lt":
+			setBound(schema, "maxLength", "maximum", "maxItems", param, true)
+		case "len":
+			setBound(schema, "minLength", "minimum", "minItems", param, false)
+			setBound(schema, "maxLength", "maximum", "maxItems", param, false)
+		case "oneof":
+			enum := []interface{}{}
+			for _, v := range matchOneOfValue.FindAllString(param, -1) {
+				enum = append(enum, enumValue(schema, strings.Trim(v, "'")))
+			}
+			schema["enum"] = enum
+		case "startswith":
+			schema["pattern"] = "^" + regexp.QuoteMeta(param)
+		case "endswith":
+			schema["pattern"] = regexp.QuoteMeta(param) + "$"
+		case "contains":
+			schema["pattern"] = regexp.QuoteMeta(param)
+		default:
+			if f, ok := validationFormats[name]; ok {
+				schema["format"] = f
+			} else if p, ok := validationPatterns[name]; ok {
+				schema["pattern"] = p
+			}
+		}
+	}
+	return required
+}
+
+// setBound sets the length, value or item count bound that applies to the
+// schema's type. exclusive bounds on lengths and counts are shifted by one
+func setBound(schema map[string]interface{}, length, value, items, param string, exclusive bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema["type"] {
+	case "integer", "number":
+		schema[value] = number(n)
+		if exclusive {
+			schema["exclusive"+strings.ToUpper(value[:1])+value[1:]] = true
+		}
+		return
+	case "string":
+		schema[length] = lengthBound(length, n, exclusive)
+	case "array", "object":
+		if schema["type"] == "object" {
+			items = strings.Replace(items, "Items", "Properties", 1)
+		}
+		schema[items] = lengthBound(items, n, exclusive)
+	}
+}
+
+func lengthBound(key string, n float64, exclusive bool) int64 {
+	if exclusive && strings.HasPrefix(key, "min") {
+		n++
+	} else if exclusive {
+		n--
+	}
+	return int64(n)
+}
+
+// number returns n as an int64 if it has no fraction, so that the yaml
+// output reads naturally
+func number(n float64) interface{} {
+	if n == math.Trunc(n) {
+		return int64(n)
+	}
+	return n
+}
+
+func enumValue(schema map[string]interface{}, v string) interface{} {
+	switch schema["type"] {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return number(n)
+		}
+	}
+	return v
+}
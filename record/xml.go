@@ -0,0 +1,305 @@
+package autodoc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// xmlElement is a parsed XML element
+type xmlElement struct {
+	name     string
+	attrs    []xml.Attr
+	text     string
+	children []*xmlElement
+}
+
+func parseXML(b []byte) *xmlElement {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	stack := []*xmlElement{}
+	var root *xmlElement
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			e := &xmlElement{
+				name: tok.Name.Local,
+			}
+			for _, a := range tok.Attr {
+				// namespace declarations are not part of the data
+				if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+					continue
+				}
+				e.attrs = append(e.attrs, a)
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, e)
+			} else if root == nil {
+				root = e
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(tok)
+			}
+		}
+	}
+	return root
+}
+
+// getXMLSchema infers the schema of an XML body. attributes are marked with
+// xml.attribute, and elements that only hold a repeated child become wrapped
+// arrays. the root element name is kept in xml.name
+func getXMLSchema(b []byte) map[string]interface{} {
+	root := parseXML(b)
+	if root == nil {
+		return map[string]interface{}{}
+	}
+
+	m := getXMLType(root)
+	setXMLName(m, root.name)
+	return m
+}
+
+func getXMLType(e *xmlElement) map[string]interface{} {
+	if len(e.attrs) == 0 && len(e.children) == 0 {
+		return xmlValue(strings.TrimSpace(e.text))
+	}
+
+	// <items><item/><item/></items>
+	if len(e.attrs) == 0 && len(e.children) > 1 && sameName(e.children) {
+		var items map[string]interface{}
+		for _, c := range e.children {
+			items = mergeXMLItems(items, getXMLType(c))
+		}
+		setXMLName(items, e.children[0].name)
+		m := map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		}
+		m["xml"] = map[string]interface{}{
+			"wrapped": true,
+		}
+		return m
+	}
+
+	props := map[string]interface{}{}
+	required := []string{}
+	for _, a := range e.attrs {
+		prop := xmlValue(a.Value)
+		prop["xml"] = map[string]interface{}{
+			"attribute": true,
+		}
+		props[a.Name.Local] = prop
+		required = append(required, a.Name.Local)
+	}
+
+	counts := map[string]int{}
+	for _, c := range e.children {
+		counts[c.name]++
+	}
+	for _, c := range e.children {
+		s := getXMLType(c)
+		if counts[c.name] > 1 {
+			// unwrapped repeated elements, e.g. <user><tag/><tag/></user>
+			s = map[string]interface{}{
+				"type":  "array",
+				"items": s,
+			}
+		}
+
+		prev, ok := props[c.name].(map[string]interface{})
+		if !ok {
+			required = append(required, c.name)
+			props[c.name] = s
+			continue
+		}
+		if counts[c.name] > 1 {
+			prev["items"] = mergeXMLItems(getSchema(prev["items"]), getSchema(s["items"]))
+			continue
+		}
+		props[c.name] = mergeXMLSchema(prev, s)
+	}
+
+	m := map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		m["required"] = required
+	}
+	return m
+}
+
+// xmlDroppedText returns the names of the elements whose text isn't part of
+// the schema. OpenAPI can't describe the text of an element that also has
+// attributes or children, e.g. <price currency="EUR">5</price>
+func xmlDroppedText(b []byte) []string {
+	names := []string{}
+	var walk func(e *xmlElement)
+	walk = func(e *xmlElement) {
+		if (len(e.attrs) > 0 || len(e.children) > 0) && strings.TrimSpace(e.text) != "" {
+			names = append(names, e.name)
+		}
+		for _, c := range e.children {
+			walk(c)
+		}
+	}
+	if root := parseXML(b); root != nil {
+		walk(root)
+	}
+	return names
+}
+
+// xmlWarnings reports the elements of the documented XML bodies whose text
+// isn't part of the schema
+func (re *Recorder) xmlWarnings() []Warning {
+	warnings := []Warning{}
+	add := func(url string, ct string, b []byte) {
+		if !isXML(ct) {
+			return
+		}
+		for _, name := range xmlDroppedText(b) {
+			warnings = append(warnings, Warning{
+				Method:  re.Method,
+				Path:    re.Path,
+				URL:     url,
+				Message: fmt.Sprintf("text of XML element <%s> with attributes or children is not documented", name),
+			})
+		}
+	}
+
+	for _, rec := range re.Records {
+		if !rec.documented() {
+			continue
+		}
+		req := rec.Request
+		if req.PostData != nil && rec.Options.UseAsRequestExample && !rec.Options.ExcludeFromOpenAPI {
+			add(req.URL, getContentType(req.Headers, []byte(req.PostData.Text)), []byte(req.PostData.Text))
+		}
+		if res := rec.Response; res.Content != nil {
+			add(req.URL, getContentType(res.Headers, res.Content.Text), res.Content.Text)
+		}
+	}
+	return warnings
+}
+
+// xmlValue returns the schema of a text value, inferred like a parameter
+// value, with the example converted to that type
+func xmlValue(v string) map[string]interface{} {
+	m := map[string]interface{}{
+		"type": getParamType(v),
+	}
+	m["example"] = paramValue(v, m)
+	return m
+}
+
+// mergeXMLSchema merges two inferred XML schemas. an element that repeats in
+// one body but not in another is an array in both, rather than a oneOf
+func mergeXMLSchema(a, b map[string]interface{}) map[string]interface{} {
+	return mergeSchema(alignXML(a, b), alignXML(b, a))
+}
+
+func mergeXMLItems(a, b map[string]interface{}) map[string]interface{} {
+	return mergeItems(alignXML(a, b), alignXML(b, a))
+}
+
+// alignXML reshapes a to the array shape of b, wherever b is an array of
+// what a holds once
+func alignXML(a, b map[string]interface{}) map[string]interface{} {
+	if a == nil || b == nil {
+		return a
+	}
+	if b["type"] == "array" && a["type"] != "array" {
+		a = asXMLArray(a, b)
+	}
+
+	switch a["type"] {
+	case "array":
+		items, other := getSchema(a["items"]), getSchema(b["items"])
+		if items != nil && other != nil {
+			a = copySchema(a, nil)
+			a["items"] = alignXML(items, other)
+		}
+	case "object":
+		pa, pb := getSchema(a["properties"]), getSchema(b["properties"])
+		if pa != nil && pb != nil {
+			props := map[string]interface{}{}
+			for k, v := range pa {
+				props[k] = alignXML(getSchema(v), getSchema(pb[k]))
+			}
+			a = copySchema(a, nil)
+			a["properties"] = props
+		}
+	}
+	return a
+}
+
+// asXMLArray returns a as an array shaped like b. if b is a wrapped array,
+// a is expected to be its wrapper holding a single item
+func asXMLArray(a, b map[string]interface{}) map[string]interface{} {
+	if getSchema(b["xml"])["wrapped"] != true {
+		return map[string]interface{}{
+			"type":  "array",
+			"items": a,
+		}
+	}
+
+	name, _ := getSchema(getSchema(b["items"])["xml"])["name"].(string)
+	props := getSchema(a["properties"])
+	item := getSchema(props[name])
+	if a["type"] != "object" || len(props) != 1 || item == nil || getSchema(item["xml"])["attribute"] == true {
+		return a
+	}
+
+	item = copySchema(item, nil)
+	setXMLName(item, name)
+	x := map[string]interface{}{
+		"wrapped": true,
+	}
+	if n, ok := getSchema(a["xml"])["name"]; ok {
+		x["name"] = n
+	}
+	return map[string]interface{}{
+		"type":  "array",
+		"items": item,
+		"xml":   x,
+	}
+}
+
+func sameName(es []*xmlElement) bool {
+	for _, e := range es {
+		if e.name != es[0].name {
+			return false
+		}
+	}
+	return true
+}
+
+// setXMLName adds an xml name hint to the schema, keeping its other xml
+// annotations
+func setXMLName(m map[string]interface{}, name string) {
+	x := map[string]interface{}{}
+	for k, v := range getSchema(m["xml"]) {
+		x[k] = v
+	}
+	x["name"] = name
+	m["xml"] = x
+}
+
+func getSchema(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	return m
+}
@@ -0,0 +1,116 @@
+package autodoc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetXMLSchema(t *testing.T) {
+	body := `<?xml version="1.0"?>
+<order id="7" xmlns="urn:example">
+	<items>
+		<item sku="a"><qty>1</qty></item>
+		<item sku="b"><qty>2</qty><note>gift</note></item>
+	</items>
+	<tag>x</tag>
+	<tag>y</tag>
+</order>`
+
+	want := map[string]interface{}{
+		"type": "object",
+		"xml":  map[string]interface{}{"name": "order"},
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":    "integer",
+				"example": int64(7),
+				"xml":     map[string]interface{}{"attribute": true},
+			},
+			"items": map[string]interface{}{
+				"type": "array",
+				"xml":  map[string]interface{}{"wrapped": true},
+				"items": map[string]interface{}{
+					"type": "object",
+					"xml":  map[string]interface{}{"name": "item"},
+					"properties": map[string]interface{}{
+						"sku": map[string]interface{}{
+							"type":    "string",
+							"example": "a",
+							"xml":     map[string]interface{}{"attribute": true},
+						},
+						"qty":  map[string]interface{}{"type": "integer", "example": int64(1)},
+						"note": map[string]interface{}{"type": "string", "example": "gift"},
+					},
+					"required": []string{"qty", "sku"},
+				},
+			},
+			"tag": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string", "example": "x"},
+			},
+		},
+		"required": []string{"id", "items", "tag"},
+	}
+
+	got := getXMLSchema([]byte(body))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getXMLSchema() = %#v, want %#v", got, want)
+	}
+}
+
+func TestGetXMLSchemaText(t *testing.T) {
+	want := map[string]interface{}{
+		"type": "object",
+		"xml":  map[string]interface{}{"name": "price"},
+		"properties": map[string]interface{}{
+			"currency": map[string]interface{}{
+				"type":    "string",
+				"example": "EUR",
+				"xml":     map[string]interface{}{"attribute": true},
+			},
+		},
+		"required": []string{"currency"},
+	}
+
+	got := getXMLSchema([]byte(`<price currency="EUR">5</price>`))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getXMLSchema() = %#v, want %#v", got, want)
+	}
+
+	// the dropped text is reported instead
+	re := Recorder{Path: "/price", Method: "get"}
+	re.Record(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<price currency="EUR">5</price>`))
+	})(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/price", nil))
+
+	warnings := re.OpenAPI().Warnings
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "<price>") {
+		t.Errorf("warnings = %v, want one for <price>", warnings)
+	}
+}
+
+func TestMergeXMLSchema(t *testing.T) {
+	one := getXMLSchema([]byte(`<order><items><item>a</item></items><tag>x</tag></order>`))
+	many := getXMLSchema([]byte(`<order><items><item>b</item><item>c</item></items><tag>y</tag><tag>z</tag></order>`))
+
+	for _, got := range []map[string]interface{}{mergeXMLSchema(one, many), mergeXMLSchema(many, one)} {
+		props := got["properties"].(map[string]interface{})
+
+		items := props["items"].(map[string]interface{})
+		if items["type"] != "array" || !reflect.DeepEqual(items["xml"], map[string]interface{}{"wrapped": true}) {
+			t.Errorf("items = %#v, want a wrapped array", items)
+		}
+		item := items["items"].(map[string]interface{})
+		if item["type"] != "string" || !reflect.DeepEqual(item["xml"], map[string]interface{}{"name": "item"}) {
+			t.Errorf("item = %#v, want a string named item", item)
+		}
+
+		tag := props["tag"].(map[string]interface{})
+		if tag["type"] != "array" || tag["items"].(map[string]interface{})["type"] != "string" {
+			t.Errorf("tag = %#v, want an array of strings", tag)
+		}
+	}
+}